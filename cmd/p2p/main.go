@@ -7,8 +7,20 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/ca"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/contacts"
+	"github.com/ansuman12chat/p2p/pkg/devices"
+	"github.com/ansuman12chat/p2p/pkg/identity"
+	"github.com/ansuman12chat/p2p/pkg/peers"
+	"github.com/ansuman12chat/p2p/pkg/profile"
+	"github.com/ansuman12chat/p2p/pkg/pull"
 	"github.com/ansuman12chat/p2p/pkg/receive"
+	"github.com/ansuman12chat/p2p/pkg/relay"
+	"github.com/ansuman12chat/p2p/pkg/rendezvous"
 	"github.com/ansuman12chat/p2p/pkg/send"
+	"github.com/ansuman12chat/p2p/pkg/share"
+	"github.com/ansuman12chat/p2p/pkg/swarmkey"
 )
 
 var (
@@ -17,6 +29,7 @@ var (
 
 func main() {
 	verTag := fmt.Sprintf("v%s", RawVersion)
+	commons.Version = verTag
 	app := &cli.App{
 		Name: "p2p",
 		Authors: []*cli.Author{
@@ -31,6 +44,19 @@ func main() {
 		Commands: []*cli.Command{
 			send.Command,
 			receive.Command,
+			share.Command,
+			pull.Command,
+			pull.ListCommand,
+			pull.GetCommand,
+			peers.Command,
+			contacts.Command,
+			profile.Command,
+			rendezvous.Command,
+			relay.Command,
+			swarmkey.Command,
+			ca.Command,
+			devices.Command,
+			identity.Command,
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -38,6 +64,11 @@ func main() {
 				Aliases: []string{"c"},
 				Usage:   "Load configuration from `FILE`",
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				EnvVars: []string{"P2P_PROFILE"},
+				Usage:   "Use the settings and identity of profile `NAME`",
+			},
 		},
 	}
 
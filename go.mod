@@ -3,6 +3,7 @@ module github.com/ansuman12chat/p2p
 go 1.21.3
 
 require (
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/adrg/xdg v0.4.0 // indirect
 	github.com/benbjohnson/clock v1.3.5 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
@@ -7,6 +7,8 @@ import (
 type Ioutiler interface {
 	ReadFile(filename string) ([]byte, error)
 	WriteFile(filename string, data []byte, perm os.FileMode) error
+	ReadDir(dirname string) ([]os.DirEntry, error)
+	RemoveAll(path string) error
 }
 
 type Ioutil struct{}
@@ -18,3 +20,11 @@ func (a Ioutil) ReadFile(filename string) ([]byte, error) {
 func (a Ioutil) WriteFile(filename string, data []byte, perm os.FileMode) error {
 	return os.WriteFile(filename, data, perm)
 }
+
+func (a Ioutil) ReadDir(dirname string) ([]os.DirEntry, error) {
+	return os.ReadDir(dirname)
+}
+
+func (a Ioutil) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
@@ -9,6 +9,7 @@ import (
 // applications should use the XDG defined locations instead of hardcoding paths.
 type Xdger interface {
 	ConfigFile(relPath string) (string, error)
+	ConfigHome() string
 }
 
 type Xdg struct{}
@@ -16,3 +17,7 @@ type Xdg struct{}
 func (a Xdg) ConfigFile(relPath string) (string, error) {
 	return stdxdg.ConfigFile(relPath)
 }
+
+func (a Xdg) ConfigHome() string {
+	return stdxdg.ConfigHome
+}
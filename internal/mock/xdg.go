@@ -47,3 +47,17 @@ func (mr *MockXdgerMockRecorder) ConfigFile(relPath interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigFile", reflect.TypeOf((*MockXdger)(nil).ConfigFile), relPath)
 }
+
+// ConfigHome mocks base method.
+func (m *MockXdger) ConfigHome() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigHome")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ConfigHome indicates an expected call of ConfigHome.
+func (mr *MockXdgerMockRecorder) ConfigHome() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigHome", reflect.TypeOf((*MockXdger)(nil).ConfigHome))
+}
@@ -0,0 +1,212 @@
+package ca
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:  "ca",
+	Usage: "Manages a team certificate authority.",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "init",
+			Usage:  "Creates a new certificate authority and prints its ID.",
+			Action: InitAction,
+		},
+		{
+			Name:      "issue",
+			Usage:     "Issues a certificate that binds a peer ID to a name.",
+			ArgsUsage: "NAME PEER_ID",
+			Action:    IssueAction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "role",
+					Usage: "An optional role of the peer within the team, e.g. admin.",
+				},
+				&cli.DurationFlag{
+					Name:  "valid",
+					Usage: "How long the certificate is valid.",
+					Value: 365 * 24 * time.Hour,
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Write the certificate to `FILE` instead of printing it.",
+				},
+			},
+		},
+		{
+			Name:      "revoke",
+			Usage:     "Revokes certificates and writes the updated revocation list.",
+			ArgsUsage: "SERIAL|PEER_ID|NAME",
+			Action:    RevokeAction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Write the revocation list to `FILE` instead of printing it.",
+				},
+			},
+		},
+	},
+	Description: `A team certificate authority signs certificates that bind the peer IDs of team members to
+names and optional roles. Members put their certificate's path into the Certificate setting
+and present it to other peers. Receivers that list the ID of the authority in the TrustedCAs
+setting trust every peer with a valid certificate like a verified contact.
+
+Revoked certificates are listed in a signed revocation list. Distribute it to all members
+and add its path to the RevocationLists setting.`,
+}
+
+// InitAction creates the certificate authority of the profile.
+func InitAction(c *cli.Context) error {
+	ca, err := config.LoadCA(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	// Don't replace the key that all certificates depend on by accident.
+	if ca.Exists {
+		return fmt.Errorf("a certificate authority already exists at %s", ca.Path)
+	}
+
+	key, id, err := node.GenerateCAKey()
+	if err != nil {
+		return err
+	}
+
+	if err = ca.SetPrivateKey(key); err != nil {
+		return err
+	}
+
+	if err = ca.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Created certificate authority %s\n", id)
+	log.Infoln("Add its ID to the TrustedCAs setting of your team members.")
+	return nil
+}
+
+// IssueAction issues a certificate for the peer given by the arguments.
+func IssueAction(c *cli.Context) error {
+	if c.Args().Len() < 2 {
+		return fmt.Errorf("please specify the name and the peer ID to certify")
+	}
+
+	peerID, err := peer.Decode(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	ca, key, err := loadCA(c)
+	if err != nil {
+		return err
+	}
+
+	cert, err := node.IssueCertificate(key, peerID, c.Args().First(), c.String("role"), c.Duration("valid"))
+	if err != nil {
+		return err
+	}
+
+	data, err := node.EncodeCertificate(cert)
+	if err != nil {
+		return err
+	}
+
+	ca.Issued = append(ca.Issued, &config.IssuedCertificate{
+		Serial:   cert.Serial,
+		PeerID:   cert.PeerId,
+		Name:     cert.Name,
+		Role:     cert.Role,
+		NotAfter: time.Unix(cert.NotAfter, 0),
+	})
+	if err = ca.Save(); err != nil {
+		return err
+	}
+
+	if err = output(c.String("output"), data); err != nil {
+		return err
+	}
+
+	log.Infof("Issued certificate %s for %s\n", cert.Serial, cert.Name)
+	return nil
+}
+
+// RevokeAction revokes the certificates given by the argument.
+func RevokeAction(c *cli.Context) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("please specify the serial, peer ID or name of the certificates to revoke")
+	}
+
+	ca, key, err := loadCA(c)
+	if err != nil {
+		return err
+	}
+
+	revoked := ca.Revoke(c.Args().First())
+	if len(revoked) == 0 {
+		return fmt.Errorf("no valid certificate matches %s", c.Args().First())
+	}
+
+	list, err := node.SignRevocationList(key, ca.RevokedSerials())
+	if err != nil {
+		return err
+	}
+
+	data, err := node.EncodeRevocationList(list)
+	if err != nil {
+		return err
+	}
+
+	if err = ca.Save(); err != nil {
+		return err
+	}
+
+	if err = output(c.String("output"), data); err != nil {
+		return err
+	}
+
+	for _, cert := range revoked {
+		log.Infof("Revoked certificate %s for %s\n", cert.Serial, cert.Name)
+	}
+	return nil
+}
+
+// loadCA loads the certificate authority of the profile and its key.
+func loadCA(c *cli.Context) (*config.CA, crypto.PrivKey, error) {
+	ca, err := config.LoadCA(c.String("profile"))
+	if err != nil {
+		return nil, nil, err
+	} else if !ca.Exists {
+		return nil, nil, fmt.Errorf("no certificate authority found, please create one with p2p ca init")
+	}
+
+	key, err := ca.PrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ca, key, nil
+}
+
+// output writes the given data to the given file or prints it
+// if the path is empty.
+func output(path string, data []byte) error {
+	if path == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,72 @@
+package commons
+
+import (
+	"context"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+)
+
+// RendezvousFlags configure the rendezvous server used to find
+// peers outside the local network.
+var RendezvousFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "rendezvous",
+		EnvVars: []string{"P2P_RENDEZVOUS"},
+		Usage:   "Also find peers via the rendezvous server at the given multiaddress.",
+	},
+	&cli.StringFlag{
+		Name:    "rendezvous-namespace",
+		EnvVars: []string{"P2P_RENDEZVOUS_NAMESPACE"},
+		Usage:   "The namespace to register and look for peers in at the rendezvous server.",
+		Value:   "default",
+	},
+}
+
+// BroadcastFlags enable the UDP broadcast discovery for networks
+// that drop multicast traffic.
+var BroadcastFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "broadcast",
+		EnvVars: []string{"P2P_BROADCAST"},
+		Usage:   "Also find peers via UDP broadcast beacons, e.g. if your network drops multicast.",
+	},
+	&cli.IntFlag{
+		Name:    "broadcast-port",
+		EnvVars: []string{"P2P_BROADCAST_PORT"},
+		Usage:   "The UDP port at which beacons are sent and received.",
+		Value:   44046,
+	},
+}
+
+// DiscoveryFlags are all flags that configure how peers are found
+// besides mDNS.
+var DiscoveryFlags = append(append([]cli.Flag{}, RendezvousFlags...), BroadcastFlags...)
+
+// RelayFlags configure the circuit relay used to reach peers that
+// can't be connected to directly.
+var RelayFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "relay",
+		EnvVars: []string{"P2P_RELAY"},
+		Usage:   "Use the circuit relay at the given multiaddress to reach peers that can't be connected to directly, or to be reached by them.",
+	},
+}
+
+// SwarmKeyFlag selects the pre-shared key of a private swarm.
+var SwarmKeyFlag = &cli.StringFlag{
+	Name:    "swarm-key",
+	EnvVars: []string{"P2P_SWARM_KEY"},
+	Usage:   "Only connect to peers of the private swarm with the key in the given file. Overrides the SwarmKey setting.",
+}
+
+// ApplySwarmKeyFlag overrides the SwarmKey setting of the configuration
+// in the given context with the SwarmKeyFlag if it's set.
+func ApplySwarmKeyFlag(ctx context.Context, c *cli.Context) {
+	if path := c.String(SwarmKeyFlag.Name); path != "" {
+		if conf, ok := config.FromContext(ctx); ok {
+			conf.Settings.SwarmKey = path
+		}
+	}
+}
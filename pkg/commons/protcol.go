@@ -8,3 +8,7 @@ const (
 	MdnsServiceReceive = "p2p/receive"
 	MdnsServiceSend    = "p2p/send"
 )
+
+// Version is the version of the p2p application that is
+// advertised to other peers. It is set by the main package.
+var Version = "dev"
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const addressBookFilename = "addressbook.json"
+
+// Contact is a known peer that the user gave a name.
+type Contact struct {
+	// The name the user chose for the peer.
+	Name string
+
+	// The base58 encoded peer ID.
+	PeerID string
+
+	// Multiaddresses at which the peer is known to be reachable.
+	Addrs []string
+
+	// Whether the user compared the short authentication string
+	// with the peer, so its key is known to be genuine.
+	Verified bool
+}
+
+// AddressBook contains the contacts of the user.
+type AddressBook struct {
+	Contacts []*Contact
+
+	Path    string `json:"-"`
+	Exists  bool   `json:"-"`
+	profile string
+}
+
+func LoadAddressBook(profile string) (*AddressBook, error) {
+	path, err := appXdg.ConfigFile(profileFile(profile, addressBookFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	ab := &AddressBook{Path: path, profile: profile}
+	data, err := appIoutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &ab)
+		if err != nil {
+			return nil, err
+		}
+		ab.Exists = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return ab, nil
+}
+
+// Save persists the address book to disk.
+func (a *AddressBook) Save() error {
+	err := save(profileFile(a.profile, addressBookFilename), a, 0744)
+	if err == nil {
+		a.Exists = true
+	}
+	return err
+}
+
+// Lookup returns the contact with the given name or peer ID.
+func (a *AddressBook) Lookup(nameOrID string) (*Contact, bool) {
+	for _, c := range a.Contacts {
+		if c.Name == nameOrID || c.PeerID == nameOrID {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ByPeerID returns the contact with the given peer ID.
+func (a *AddressBook) ByPeerID(peerID string) (*Contact, bool) {
+	for _, c := range a.Contacts {
+		if c.PeerID == peerID {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Add adds the given contact or updates the one with the same peer ID.
+// A verified contact stays verified, because the peer ID is derived
+// from its key. It returns an error if the name is already taken by
+// another peer.
+func (a *AddressBook) Add(contact *Contact) error {
+	for _, c := range a.Contacts {
+		if c.Name == contact.Name && c.PeerID != contact.PeerID {
+			return fmt.Errorf("contact %q already exists with peer ID %s", c.Name, c.PeerID)
+		}
+	}
+
+	if c, found := a.ByPeerID(contact.PeerID); found {
+		contact.Verified = contact.Verified || c.Verified
+		*c = *contact
+		return nil
+	}
+
+	a.Contacts = append(a.Contacts, contact)
+	return nil
+}
+
+// Remove deletes the contact with the given name or peer ID. It
+// returns false if no such contact exists.
+func (a *AddressBook) Remove(nameOrID string) bool {
+	for i, c := range a.Contacts {
+		if c.Name == nameOrID || c.PeerID == nameOrID {
+			a.Contacts = append(a.Contacts[:i], a.Contacts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/internal/mock"
+)
+
+func TestLoadAddressBook_happyPath(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.
+		EXPECT().
+		ConfigFile(gomock.Eq(profileFile("", addressBookFilename))).
+		Return("path", nil)
+
+	data := []byte(`{"Contacts":[{"Name":"alice","PeerID":"peer-id"}]}`)
+	mioutil.
+		EXPECT().
+		ReadFile(gomock.Eq("path")).
+		Return(data, nil)
+
+	ab, err := LoadAddressBook("")
+	require.NoError(t, err)
+	assert.True(t, ab.Exists)
+
+	c, found := ab.Lookup("alice")
+	assert.True(t, found)
+	assert.Equal(t, "peer-id", c.PeerID)
+}
+
+func TestLoadAddressBook_returnsEmptyAddressBookIfFileDoesNotExist(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.EXPECT().ConfigFile(gomock.Any()).Return("path", nil)
+	mioutil.EXPECT().ReadFile(gomock.Eq("path")).Return(nil, os.ErrNotExist)
+
+	ab, err := LoadAddressBook("")
+	require.NoError(t, err)
+	assert.False(t, ab.Exists)
+	assert.Empty(t, ab.Contacts)
+}
+
+func TestAddressBook_Add_updatesExistingPeer(t *testing.T) {
+	ab := &AddressBook{}
+	require.NoError(t, ab.Add(&Contact{Name: "alice", PeerID: "peer-id-1"}))
+	require.NoError(t, ab.Add(&Contact{Name: "alice-laptop", PeerID: "peer-id-1"}))
+
+	assert.Len(t, ab.Contacts, 1)
+	_, found := ab.Lookup("alice-laptop")
+	assert.True(t, found)
+}
+
+func TestAddressBook_Add_keepsVerification(t *testing.T) {
+	ab := &AddressBook{}
+	require.NoError(t, ab.Add(&Contact{Name: "alice", PeerID: "peer-id-1", Verified: true}))
+	require.NoError(t, ab.Add(&Contact{Name: "alice-laptop", PeerID: "peer-id-1"}))
+
+	c, found := ab.Lookup("alice-laptop")
+	require.True(t, found)
+	assert.True(t, c.Verified)
+}
+
+func TestAddressBook_Add_refusesDuplicateName(t *testing.T) {
+	ab := &AddressBook{}
+	require.NoError(t, ab.Add(&Contact{Name: "alice", PeerID: "peer-id-1"}))
+	assert.Error(t, ab.Add(&Contact{Name: "alice", PeerID: "peer-id-2"}))
+}
+
+func TestAddressBook_Remove(t *testing.T) {
+	ab := &AddressBook{}
+	require.NoError(t, ab.Add(&Contact{Name: "alice", PeerID: "peer-id-1"}))
+	require.NoError(t, ab.Add(&Contact{Name: "bob", PeerID: "peer-id-2"}))
+
+	assert.True(t, ab.Remove("peer-id-1"))
+	assert.False(t, ab.Remove("alice"))
+
+	_, found := ab.ByPeerID("peer-id-2")
+	assert.True(t, found)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const blocklistFilename = "blocklist.json"
+
+// Blocklist contains the peers the user doesn't want to
+// exchange anything with.
+type Blocklist struct {
+	// The base58 encoded peer IDs of the blocked peers.
+	PeerIDs []string
+
+	Path    string `json:"-"`
+	Exists  bool   `json:"-"`
+	profile string
+}
+
+func LoadBlocklist(profile string) (*Blocklist, error) {
+	path, err := appXdg.ConfigFile(profileFile(profile, blocklistFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	bl := &Blocklist{Path: path, profile: profile}
+	data, err := appIoutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &bl)
+		if err != nil {
+			return nil, err
+		}
+		bl.Exists = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return bl, nil
+}
+
+// Save persists the blocklist to disk.
+func (b *Blocklist) Save() error {
+	err := save(profileFile(b.profile, blocklistFilename), b, 0744)
+	if err == nil {
+		b.Exists = true
+	}
+	return err
+}
+
+// Contains returns true if the given peer ID is blocked.
+func (b *Blocklist) Contains(peerID string) bool {
+	for _, id := range b.PeerIDs {
+		if id == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Add blocks the given peer ID. It returns false if
+// the peer is already blocked.
+func (b *Blocklist) Add(peerID string) bool {
+	if b.Contains(peerID) {
+		return false
+	}
+	b.PeerIDs = append(b.PeerIDs, peerID)
+	return true
+}
+
+// Remove unblocks the given peer ID. It returns false if
+// the peer isn't blocked.
+func (b *Blocklist) Remove(peerID string) bool {
+	for i, id := range b.PeerIDs {
+		if id == peerID {
+			b.PeerIDs = append(b.PeerIDs[:i], b.PeerIDs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/internal/mock"
+)
+
+func TestLoadBlocklist_happyPath(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.
+		EXPECT().
+		ConfigFile(gomock.Eq(profileFile("", blocklistFilename))).
+		Return("path", nil)
+
+	mioutil.
+		EXPECT().
+		ReadFile(gomock.Eq("path")).
+		Return([]byte(`{"PeerIDs":["peer-id"]}`), nil)
+
+	bl, err := LoadBlocklist("")
+	require.NoError(t, err)
+	assert.True(t, bl.Exists)
+	assert.True(t, bl.Contains("peer-id"))
+}
+
+func TestLoadBlocklist_returnsEmptyBlocklistIfFileDoesNotExist(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.EXPECT().ConfigFile(gomock.Any()).Return("path", nil)
+	mioutil.EXPECT().ReadFile(gomock.Eq("path")).Return(nil, os.ErrNotExist)
+
+	bl, err := LoadBlocklist("")
+	require.NoError(t, err)
+	assert.False(t, bl.Exists)
+	assert.Empty(t, bl.PeerIDs)
+}
+
+func TestBlocklist_AddRemove(t *testing.T) {
+	bl := &Blocklist{}
+	assert.True(t, bl.Add("peer-id-1"))
+	assert.False(t, bl.Add("peer-id-1"))
+	assert.True(t, bl.Add("peer-id-2"))
+
+	assert.True(t, bl.Remove("peer-id-1"))
+	assert.False(t, bl.Remove("peer-id-1"))
+	assert.Equal(t, []string{"peer-id-2"}, bl.PeerIDs)
+}
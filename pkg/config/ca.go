@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+const caFilename = "ca.json"
+
+// CA is the team certificate authority that is managed with this
+// profile. Only the machine of the team's administrator has one.
+type CA struct {
+	// The encoded private key of the authority.
+	Key string
+
+	// The certificates the authority issued.
+	Issued []*IssuedCertificate
+
+	Path    string `json:"-"`
+	Exists  bool   `json:"-"`
+	profile string
+}
+
+// IssuedCertificate records a certificate, so it can be revoked later.
+type IssuedCertificate struct {
+	Serial   string
+	PeerID   string
+	Name     string
+	Role     string
+	NotAfter time.Time
+	Revoked  bool
+}
+
+func LoadCA(profile string) (*CA, error) {
+	if err := ValidateProfile(profile); err != nil {
+		return nil, err
+	}
+
+	path, err := appXdg.ConfigFile(profileFile(profile, caFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CA{Path: path, profile: profile}
+	data, err := appIoutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &ca)
+		if err != nil {
+			return nil, err
+		}
+		ca.Exists = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return ca, nil
+}
+
+// Save persists the authority to disk. Like the identity, it's
+// only accessible by the owner.
+func (c *CA) Save() error {
+	err := save(profileFile(c.profile, caFilename), c, 0700)
+	if err == nil {
+		c.Exists = true
+	}
+	return err
+}
+
+// SetPrivateKey encodes the given key, so that it can be
+// persisted to disk.
+func (c *CA) SetPrivateKey(key crypto.PrivKey) error {
+	data, err := crypto.MarshalPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	c.Key = crypto.ConfigEncodeKey(data)
+	return nil
+}
+
+// PrivateKey returns the private key of the authority.
+func (c *CA) PrivateKey() (crypto.PrivKey, error) {
+	data, err := crypto.ConfigDecodeKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.UnmarshalPrivateKey(data)
+}
+
+// Revoke marks the certificates with the given serial, or all of the
+// given peer ID or name, as revoked. It returns the newly revoked ones.
+func (c *CA) Revoke(serialOrPeer string) []*IssuedCertificate {
+	var revoked []*IssuedCertificate
+	for _, cert := range c.Issued {
+		if cert.Revoked {
+			continue
+		}
+		if cert.Serial == serialOrPeer || cert.PeerID == serialOrPeer || cert.Name == serialOrPeer {
+			cert.Revoked = true
+			revoked = append(revoked, cert)
+		}
+	}
+	return revoked
+}
+
+// RevokedSerials returns the serials of all revoked certificates.
+func (c *CA) RevokedSerials() []string {
+	var serials []string
+	for _, cert := range c.Issued {
+		if cert.Revoked {
+			serials = append(serials, cert.Serial)
+		}
+	}
+	return serials
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ansuman12chat/p2p/internal/mock"
+)
+
+func TestCA_Revoke_revokesAllCertificatesOfPeer(t *testing.T) {
+	ca := &CA{Issued: []*IssuedCertificate{
+		{Serial: "1", PeerID: "peer-id-1", Name: "alice"},
+		{Serial: "2", PeerID: "peer-id-2", Name: "bob"},
+		{Serial: "3", PeerID: "peer-id-1", Name: "alice"},
+	}}
+
+	assert.Len(t, ca.Revoke("peer-id-1"), 2)
+	assert.Empty(t, ca.Revoke("alice"), "already revoked")
+	assert.Equal(t, []string{"1", "3"}, ca.RevokedSerials())
+
+	assert.Len(t, ca.Revoke("2"), 1)
+	assert.Equal(t, []string{"1", "2", "3"}, ca.RevokedSerials())
+}
+
+func TestLoadCA_returnsErrorOnInvalidProfile(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	appXdg = mock.NewMockXdger(ctrl)
+
+	ca, err := LoadCA("../evil")
+	assert.Nil(t, ca)
+	assert.Error(t, err)
+}
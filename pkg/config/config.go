@@ -14,11 +14,16 @@ const (
 	ContextKey = "config"
 )
 
+const (
+	settingsFilename = "settings.json"
+	identityFilename = "identity.json"
+)
+
 var (
 	// settingsFile contains the path suffix that's appended to
 	// an XDG compliant directory to find the settings file.
-	settingsFile = filepath.Join(Prefix, "settings.json")
-	identityFile = filepath.Join(Prefix, "identity.json")
+	settingsFile = filepath.Join(Prefix, settingsFilename)
+	identityFile = filepath.Join(Prefix, identityFilename)
 )
 
 var (
@@ -31,8 +36,10 @@ var (
 // information can easier be saved with more restrict
 // access permissions as it contains the private Key.
 type Config struct {
-	Settings *Settings
-	Identity *Identity
+	Settings    *Settings
+	Identity    *Identity
+	AddressBook *AddressBook
+	Blocklist   *Blocklist
 }
 
 // Save saves the peer settings and identity information
@@ -49,30 +56,60 @@ func (c *Config) Save() error {
 		return err
 	}
 
+	err = c.AddressBook.Save()
+	if err != nil {
+		return err
+	}
+
+	err = c.Blocklist.Save()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func LoadConfig() (*Config, error) {
-	settings, err := LoadSettings()
+// LoadConfig loads the settings and identity of the given profile.
+// The empty string selects the default profile.
+func LoadConfig(profile string) (*Config, error) {
+	if err := ValidateProfile(profile); err != nil {
+		return nil, err
+	}
+
+	settings, err := LoadSettings(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := LoadIdentity(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	addressBook, err := LoadAddressBook(profile)
 	if err != nil {
 		return nil, err
 	}
 
-	identity, err := LoadIdentity()
+	blocklist, err := LoadBlocklist(profile)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Config{
-		Identity: identity,
-		Settings: settings,
+		Identity:    identity,
+		Settings:    settings,
+		AddressBook: addressBook,
+		Blocklist:   blocklist,
 	}
 
 	return c, nil
 }
 
-func FillContext(ctx context.Context) (context.Context, error) {
-	conf, err := LoadConfig()
+// FillContext loads the configuration of the given profile and
+// attaches it to the context under the ContextKey.
+func FillContext(ctx context.Context, profile string) (context.Context, error) {
+	conf, err := LoadConfig(profile)
 	if err != nil {
 		return ctx, err
 	}
@@ -80,6 +117,28 @@ func FillContext(ctx context.Context) (context.Context, error) {
 	return context.WithValue(ctx, ContextKey, conf), nil
 }
 
+// FillServiceContext works like FillContext but uses the separate
+// identity of the given service, see LoadServiceIdentity.
+func FillServiceContext(ctx context.Context, profile string, service string) (context.Context, error) {
+	conf, err := LoadConfig(profile)
+	if err != nil {
+		return ctx, err
+	}
+
+	if conf.Identity, err = LoadServiceIdentity(profile, service); err != nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, ContextKey, conf), nil
+}
+
+// FromContext returns the configuration that was attached
+// to the context by FillContext.
+func FromContext(ctx context.Context) (*Config, bool) {
+	conf, ok := ctx.Value(ContextKey).(*Config)
+	return conf, ok
+}
+
 func save(relPath string, obj interface{}, perm os.FileMode) error {
 
 	path, err := appXdg.ConfigFile(relPath)
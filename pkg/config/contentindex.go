@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const contentIndexFilename = "content-index.json"
+
+// ContentIndex remembers the content IDs of local files, so they
+// don't need to be hashed again as long as their size and
+// modification time stay the same.
+type ContentIndex struct {
+	// The indexed files by their absolute path.
+	Files map[string]*IndexedFile
+
+	Path    string `json:"-"`
+	Exists  bool   `json:"-"`
+	profile string
+}
+
+// IndexedFile is the state of a file when its content ID was computed.
+type IndexedFile struct {
+	Size    int64
+	ModTime time.Time
+	CID     string
+}
+
+func LoadContentIndex(profile string) (*ContentIndex, error) {
+	path, err := appXdg.ConfigFile(profileFile(profile, contentIndexFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	ci := &ContentIndex{Path: path, profile: profile, Files: map[string]*IndexedFile{}}
+	data, err := appIoutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &ci)
+		if err != nil {
+			return nil, err
+		}
+		if ci.Files == nil {
+			ci.Files = map[string]*IndexedFile{}
+		}
+		ci.Exists = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return ci, nil
+}
+
+// Save persists the content index to disk.
+func (ci *ContentIndex) Save() error {
+	err := save(profileFile(ci.profile, contentIndexFilename), ci, 0644)
+	if err == nil {
+		ci.Exists = true
+	}
+	return err
+}
+
+// Lookup returns the content ID of the file at the given path if it
+// was indexed with the given size and modification time.
+func (ci *ContentIndex) Lookup(path string, size int64, modTime time.Time) (string, bool) {
+	f, found := ci.Files[path]
+	if !found || f.Size != size || !f.ModTime.Equal(modTime) {
+		return "", false
+	}
+	return f.CID, true
+}
+
+// Add indexes the content ID of the file at the given path with the
+// given size and modification time.
+func (ci *ContentIndex) Add(path string, size int64, modTime time.Time, cid string) {
+	ci.Files[path] = &IndexedFile{Size: size, ModTime: modTime, CID: cid}
+}
+
+// Remove forgets the file at the given path. It returns false if it
+// wasn't indexed.
+func (ci *ContentIndex) Remove(path string) bool {
+	if _, found := ci.Files[path]; !found {
+		return false
+	}
+	delete(ci.Files, path)
+	return true
+}
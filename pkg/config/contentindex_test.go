@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/internal/mock"
+)
+
+func TestLoadContentIndex_happyPath(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.
+		EXPECT().
+		ConfigFile(gomock.Eq(profileFile("", contentIndexFilename))).
+		Return("path", nil)
+
+	mioutil.
+		EXPECT().
+		ReadFile(gomock.Eq("path")).
+		Return([]byte(`{"Files":{"/data/file":{"Size":3,"ModTime":"2024-01-02T03:04:05Z","CID":"cid"}}}`), nil)
+
+	ci, err := LoadContentIndex("")
+	require.NoError(t, err)
+	assert.True(t, ci.Exists)
+
+	c, found := ci.Lookup("/data/file", 3, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.True(t, found)
+	assert.Equal(t, "cid", c)
+}
+
+func TestContentIndex_Lookup_changedFile(t *testing.T) {
+	ci := &ContentIndex{Files: map[string]*IndexedFile{}}
+	modTime := time.Now()
+	ci.Add("/data/file", 3, modTime, "cid")
+
+	_, found := ci.Lookup("/data/file", 4, modTime)
+	assert.False(t, found)
+	_, found = ci.Lookup("/data/file", 3, modTime.Add(time.Second))
+	assert.False(t, found)
+
+	assert.True(t, ci.Remove("/data/file"))
+	assert.False(t, ci.Remove("/data/file"))
+	_, found = ci.Lookup("/data/file", 3, modTime)
+	assert.False(t, found)
+}
@@ -11,20 +11,42 @@ type Identity struct {
 	// Public/Private key information.
 	Key string
 
+	// The statements that vouch for the current key, each signed
+	// by the key it replaced, oldest first.
+	Rotations []string
+
 	// The path to the location where the identity file is saved.
 	Path string `json:"-"`
 
 	// Whether the identity file exists.
 	Exists bool `json:"-"`
+
+	// The profile this identity belongs to.
+	profile string
+
+	// The name of the identity file in the profile directory.
+	filename string
+}
+
+func LoadIdentity(profile string) (*Identity, error) {
+	return loadIdentity(profile, identityFilename)
+}
+
+// LoadServiceIdentity loads the identity the given service, e.g. the
+// relay, runs with in the given profile. It's kept apart from the
+// identity of the profile, so the service doesn't share its peer ID
+// with the nodes that send and receive files.
+func LoadServiceIdentity(profile string, service string) (*Identity, error) {
+	return loadIdentity(profile, service+"-"+identityFilename)
 }
 
-func LoadIdentity() (*Identity, error) {
-	path, err := appXdg.ConfigFile(identityFile)
+func loadIdentity(profile string, filename string) (*Identity, error) {
+	path, err := appXdg.ConfigFile(profileFile(profile, filename))
 	if err != nil {
 		return nil, err
 	}
 
-	identity := &Identity{Path: path}
+	identity := &Identity{Path: path, profile: profile, filename: filename}
 	data, err := appIoutil.ReadFile(path)
 	if err == nil {
 		err = json.Unmarshal(data, &identity)
@@ -88,9 +110,19 @@ func (i *Identity) IsInitialized() bool {
 // Save persists the identity object to disk. The location can
 // be retrieved via the Path field.
 func (i *Identity) Save() error {
-	err := save(identityFile, i, 0700)
+	err := save(profileFile(i.profile, i.filename), i, 0700)
 	if err == nil {
 		i.Exists = true
 	}
 	return err
 }
+
+// Mnemonic returns the words that encode the private key, so it can
+// be written down and restored with DecodeMnemonic.
+func (i *Identity) Mnemonic() ([]string, error) {
+	key, err := i.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return EncodeMnemonic(key)
+}
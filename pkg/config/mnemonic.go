@@ -0,0 +1,120 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+)
+
+// The version of the mnemonic encoding. It's stored in the upper half
+// of the first byte, the type of the key in the lower half.
+const mnemonicVersion = 1
+
+// The size of the private keys in mnemonics in bytes. Ed25519 keys
+// are reduced to their seed.
+const mnemonicKeySize = 32
+
+// The number of bits each word and the checksum carry.
+const (
+	mnemonicWordBits     = 11
+	mnemonicChecksumBits = 11
+)
+
+// The number of words of a mnemonic. They encode the tag byte,
+// the key and the checksum.
+const mnemonicLength = (8*(1+mnemonicKeySize) + mnemonicChecksumBits) / mnemonicWordBits
+
+// mnemonicIndex maps the words and their first four letters to
+// their position in the word list.
+var mnemonicIndex = func() map[string]int {
+	index := map[string]int{}
+	for i, w := range mnemonicWords {
+		index[w] = i
+		if len(w) > 4 {
+			index[w[:4]] = i
+		}
+	}
+	return index
+}()
+
+// EncodeMnemonic converts the given private key into words of the
+// BIP39 word list. Only Ed25519 and secp256k1 keys are supported.
+func EncodeMnemonic(key crypto.PrivKey) ([]string, error) {
+	raw, err := key.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.Type() {
+	case crypto.Ed25519:
+		raw = raw[:ed25519.SeedSize]
+	case crypto.Secp256k1:
+	default:
+		return nil, fmt.Errorf("keys of type %s can't be backed up", key.Type())
+	}
+
+	payload := append([]byte{mnemonicVersion<<4 | byte(key.Type())}, raw...)
+	bits := new(big.Int).SetBytes(payload)
+	bits.Lsh(bits, mnemonicChecksumBits)
+	bits.Or(bits, big.NewInt(mnemonicChecksum(payload)))
+
+	words := make([]string, mnemonicLength)
+	mask := big.NewInt(1<<mnemonicWordBits - 1)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = mnemonicWords[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, mnemonicWordBits)
+	}
+
+	return words, nil
+}
+
+// DecodeMnemonic converts the words that EncodeMnemonic returned back
+// into the private key. Words may be abbreviated to four letters.
+func DecodeMnemonic(words []string) (crypto.PrivKey, error) {
+	if len(words) != mnemonicLength {
+		return nil, fmt.Errorf("expected %d words, got %d", mnemonicLength, len(words))
+	}
+
+	bits := new(big.Int)
+	for i, w := range words {
+		n, found := mnemonicIndex[strings.ToLower(w)]
+		if !found {
+			return nil, fmt.Errorf("unknown word %d: %q", i+1, w)
+		}
+		bits.Lsh(bits, mnemonicWordBits)
+		bits.Or(bits, big.NewInt(int64(n)))
+	}
+
+	checksum := new(big.Int).And(bits, big.NewInt(1<<mnemonicChecksumBits-1)).Int64()
+	bits.Rsh(bits, mnemonicChecksumBits)
+	payload := bits.FillBytes(make([]byte, 1+mnemonicKeySize))
+
+	if checksum != mnemonicChecksum(payload) {
+		return nil, fmt.Errorf("invalid checksum, please check the order and spelling of the words")
+	} else if payload[0]>>4 != mnemonicVersion {
+		return nil, fmt.Errorf("unsupported mnemonic version %d", payload[0]>>4)
+	}
+
+	raw := payload[1:]
+	switch pb.KeyType(payload[0] & 0x0f) {
+	case crypto.Ed25519:
+		return crypto.UnmarshalEd25519PrivateKey(ed25519.NewKeyFromSeed(raw))
+	case crypto.Secp256k1:
+		return crypto.UnmarshalSecp256k1PrivateKey(raw)
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", payload[0]&0x0f)
+	}
+}
+
+// mnemonicChecksum returns the first bits of the SHA-256 hash of
+// the given payload.
+func mnemonicChecksum(payload []byte) int64 {
+	sum := sha256.Sum256(payload)
+	return int64(binary.BigEndian.Uint16(sum[:2]) >> (16 - mnemonicChecksumBits))
+}
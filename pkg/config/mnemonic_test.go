@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMnemonic_roundTrip(t *testing.T) {
+	for _, typ := range []int{crypto.Ed25519, crypto.Secp256k1} {
+		key, _, err := crypto.GenerateKeyPairWithReader(typ, 256, rand.Reader)
+		require.NoError(t, err)
+
+		words, err := EncodeMnemonic(key)
+		require.NoError(t, err)
+		assert.Len(t, words, 25)
+
+		restored, err := DecodeMnemonic(words)
+		require.NoError(t, err)
+		assert.True(t, key.Equals(restored), "key type %d", typ)
+	}
+}
+
+func TestDecodeMnemonic_acceptsAbbreviations(t *testing.T) {
+	key, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	require.NoError(t, err)
+
+	words, err := EncodeMnemonic(key)
+	require.NoError(t, err)
+	for i, w := range words {
+		if len(w) > 4 {
+			words[i] = strings.ToUpper(w[:4])
+		}
+	}
+
+	restored, err := DecodeMnemonic(words)
+	require.NoError(t, err)
+	assert.True(t, key.Equals(restored))
+}
+
+func TestDecodeMnemonic_detectsMistakes(t *testing.T) {
+	key, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 256)
+	require.NoError(t, err)
+
+	words, err := EncodeMnemonic(key)
+	require.NoError(t, err)
+
+	swapped := append([]string{}, words...)
+	swapped[3], swapped[4] = swapped[4], swapped[3]
+	if swapped[3] != swapped[4] {
+		_, err = DecodeMnemonic(swapped)
+		assert.Error(t, err)
+	}
+
+	_, err = DecodeMnemonic(words[1:])
+	assert.Error(t, err)
+
+	unknown := append([]string{}, words...)
+	unknown[0] = "p2p"
+	_, err = DecodeMnemonic(unknown)
+	assert.Error(t, err)
+}
+
+func TestEncodeMnemonic_rejectsUnsupportedKeyType(t *testing.T) {
+	key, _, err := crypto.GenerateKeyPair(crypto.ECDSA, 256)
+	require.NoError(t, err)
+
+	_, err = EncodeMnemonic(key)
+	assert.Error(t, err)
+}
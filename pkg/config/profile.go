@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the name under which the settings and identity
+// files directly below the Prefix directory are listed.
+const DefaultProfile = "default"
+
+// profilesDir contains the path suffix that's appended to an XDG
+// compliant directory to find the named profile directories.
+var profilesDir = filepath.Join(Prefix, "profiles")
+
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateProfile checks that the given profile name can safely
+// be used as a directory name. The empty string is valid and
+// refers to the default profile.
+func ValidateProfile(profile string) error {
+	if profile == "" || profileNameRegex.MatchString(profile) {
+		return nil
+	}
+	return fmt.Errorf("invalid profile name %q", profile)
+}
+
+// isDefaultProfile returns true if the given name refers to the
+// settings and identity files that predate named profiles.
+func isDefaultProfile(profile string) bool {
+	return profile == "" || profile == DefaultProfile
+}
+
+// profileFile returns the path suffix of the file with the given
+// name in the directory of the given profile.
+func profileFile(profile string, filename string) string {
+	if isDefaultProfile(profile) {
+		return filepath.Join(Prefix, filename)
+	}
+	return filepath.Join(profilesDir, profile, filename)
+}
+
+// ListProfiles returns the sorted names of all profiles including
+// the default profile.
+func ListProfiles() ([]string, error) {
+	entries, err := appIoutil.ReadDir(filepath.Join(appXdg.ConfigHome(), profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	profiles := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfile(entry.Name()) == nil && !isDefaultProfile(entry.Name()) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+
+	return append([]string{DefaultProfile}, profiles...), nil
+}
+
+// CreateProfile creates the settings and identity files for a new
+// profile with a freshly generated key pair.
+func CreateProfile(profile string) (*Config, error) {
+	if isDefaultProfile(profile) {
+		return nil, fmt.Errorf("the %s profile cannot be created", DefaultProfile)
+	}
+
+	conf, err := LoadConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Settings.Exists || conf.Identity.Exists {
+		return nil, fmt.Errorf("profile %q already exists", profile)
+	}
+
+	if err = conf.Identity.GenerateKeyPair(); err != nil {
+		return nil, err
+	}
+
+	if err = conf.Save(); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// DeleteProfile removes the directory of the given profile including
+// its private key.
+func DeleteProfile(profile string) error {
+	if isDefaultProfile(profile) {
+		return fmt.Errorf("the %s profile cannot be deleted", DefaultProfile)
+	}
+
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+
+	path := filepath.Join(appXdg.ConfigHome(), profilesDir, profile)
+	if _, err := appIoutil.ReadDir(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("profile %q does not exist", profile)
+		}
+		return err
+	}
+
+	return appIoutil.RemoveAll(path)
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/internal/mock"
+)
+
+type dirEntry struct {
+	name  string
+	isDir bool
+}
+
+func (d dirEntry) Name() string               { return d.name }
+func (d dirEntry) IsDir() bool                { return d.isDir }
+func (d dirEntry) Type() fs.FileMode          { return fs.ModeDir }
+func (d dirEntry) Info() (fs.FileInfo, error) { return nil, nil }
+
+func TestValidateProfile(t *testing.T) {
+	assert.NoError(t, ValidateProfile(""))
+	assert.NoError(t, ValidateProfile("ci"))
+	assert.NoError(t, ValidateProfile("work-laptop_2.0"))
+	assert.Error(t, ValidateProfile("../evil"))
+	assert.Error(t, ValidateProfile("a/b"))
+	assert.Error(t, ValidateProfile(".hidden"))
+}
+
+func TestProfileFile(t *testing.T) {
+	assert.Equal(t, settingsFile, profileFile("", settingsFilename))
+	assert.Equal(t, identityFile, profileFile(DefaultProfile, identityFilename))
+	assert.Equal(t, filepath.Join(Prefix, "profiles", "ci", settingsFilename), profileFile("ci", settingsFilename))
+}
+
+func TestLoadSettings_usesProfileDirectory(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.
+		EXPECT().
+		ConfigFile(gomock.Eq(filepath.Join(Prefix, "profiles", "ci", settingsFilename))).
+		Return("path", nil)
+
+	mioutil.
+		EXPECT().
+		ReadFile(gomock.Eq("path")).
+		Return(nil, os.ErrNotExist)
+
+	settings, err := LoadSettings("ci")
+	require.NoError(t, err)
+	assert.Equal(t, "path", settings.Path)
+}
+
+func TestLoadServiceIdentity_usesSeparateFile(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.
+		EXPECT().
+		ConfigFile(gomock.Eq(filepath.Join(Prefix, "profiles", "ci", "relay-"+identityFilename))).
+		Return("relay-path", nil).
+		Times(2)
+
+	mioutil.
+		EXPECT().
+		ReadFile(gomock.Eq("relay-path")).
+		Return(nil, os.ErrNotExist)
+
+	mioutil.
+		EXPECT().
+		WriteFile(gomock.Eq("relay-path"), gomock.Any(), gomock.Eq(os.FileMode(0700))).
+		Return(nil)
+
+	identity, err := LoadServiceIdentity("ci", "relay")
+	require.NoError(t, err)
+	assert.Equal(t, "relay-path", identity.Path)
+	assert.False(t, identity.IsInitialized())
+
+	require.NoError(t, identity.GenerateKeyPair())
+	require.NoError(t, identity.Save())
+}
+
+func TestLoadConfig_returnsErrorOnInvalidProfile(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	appXdg = mock.NewMockXdger(ctrl)
+
+	conf, err := LoadConfig("../evil")
+	assert.Nil(t, conf)
+	assert.Error(t, err)
+}
+
+func TestListProfiles_returnsDefaultIfNoProfilesExist(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.EXPECT().ConfigHome().Return("home")
+	mioutil.
+		EXPECT().
+		ReadDir(gomock.Eq(filepath.Join("home", profilesDir))).
+		Return(nil, os.ErrNotExist)
+
+	profiles, err := ListProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfile}, profiles)
+}
+
+func TestListProfiles_returnsSortedDirectories(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.EXPECT().ConfigHome().Return("home")
+	mioutil.
+		EXPECT().
+		ReadDir(gomock.Any()).
+		Return([]os.DirEntry{
+			dirEntry{"work", true},
+			dirEntry{"ci", true},
+			dirEntry{"stray-file", false},
+		}, nil)
+
+	profiles, err := ListProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfile, "ci", "work"}, profiles)
+}
+
+func TestListProfiles_returnsErrorOfReadDir(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	expectedErr := fmt.Errorf("some error")
+	mxdg.EXPECT().ConfigHome().Return("home")
+	mioutil.EXPECT().ReadDir(gomock.Any()).Return(nil, expectedErr)
+
+	profiles, err := ListProfiles()
+	assert.Nil(t, profiles)
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestDeleteProfile_refusesDefaultProfile(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	assert.Error(t, DeleteProfile(""))
+	assert.Error(t, DeleteProfile(DefaultProfile))
+}
+
+func TestDeleteProfile_removesProfileDirectory(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	path := filepath.Join("home", profilesDir, "ci")
+	mxdg.EXPECT().ConfigHome().Return("home")
+	mioutil.EXPECT().ReadDir(gomock.Eq(path)).Return([]os.DirEntry{}, nil)
+	mioutil.EXPECT().RemoveAll(gomock.Eq(path)).Return(nil)
+
+	assert.NoError(t, DeleteProfile("ci"))
+}
+
+func TestCreateProfile_refusesExistingProfile(t *testing.T) {
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mioutil := mock.NewMockIoutiler(ctrl)
+	mxdg := mock.NewMockXdger(ctrl)
+
+	appIoutil = mioutil
+	appXdg = mxdg
+
+	mxdg.EXPECT().ConfigFile(gomock.Any()).Return("path", nil).Times(4)
+	mioutil.EXPECT().ReadFile(gomock.Eq("path")).Return([]byte(`{}`), nil).Times(4)
+
+	conf, err := CreateProfile("ci")
+	assert.Nil(t, conf)
+	assert.Error(t, err)
+}
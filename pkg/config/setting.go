@@ -6,17 +6,94 @@ import (
 )
 
 type Settings struct {
-	Path   string `json:"-"`
-	Exists bool   `json:"-"`
+	// The human-readable name that is advertised to other peers.
+	// The host name is used if it's empty.
+	Nickname string
+
+	// Multiaddresses including the /p2p/ component of peers with fixed
+	// addresses. They are probed regularly and listed next to the
+	// peers discovered via mDNS.
+	StaticPeers []string
+
+	// The transport that is preferred when connecting to peers
+	// that offer several, either tcp or quic. libp2p decides if
+	// it's empty.
+	Transport string
+
+	// By default, only connections from and to private, loopback
+	// and link-local addresses are allowed. AllowPublic lifts this
+	// restriction, e.g. for relays and rendezvous servers on the
+	// internet.
+	AllowPublic bool
+
+	// CIDR ranges, e.g. 203.0.113.0/24, that are allowed in
+	// addition to the local network.
+	AllowCIDRs []string
+
+	// CIDR ranges that are never allowed. They take precedence
+	// over the allowed ones.
+	DenyCIDRs []string
+
+	// The path of the file with the pre-shared key of a private
+	// swarm. Only peers with the same key can connect, see
+	// p2p swarm-key generate.
+	SwarmKey string
+
+	// How short authentication strings are displayed, either
+	// digits or emoji. Digits are used if it's empty.
+	VerificationFormat string
+
+	// The IDs of team certificate authorities, see p2p ca. Peers
+	// with a valid certificate of one of them are trusted like
+	// verified contacts.
+	TrustedCAs []string
+
+	// The paths of the revocation lists the trusted authorities
+	// publish.
+	RevocationLists []string
+
+	// The path of the certificate a team certificate authority
+	// issued for this node. It's presented to other peers.
+	Certificate string
+
+	// The path of the statement that this device belongs to the
+	// same user as another one, see p2p devices link.
+	DeviceLink string
+
+	// Whether this device linked other ones to it, see p2p devices
+	// link. Peers are only checked for being linked devices if it's
+	// set or DeviceLink is, which saves an info request per push.
+	OwnsDevices bool
+
+	// The directory that files from linked devices are saved to
+	// without asking. The current directory is used if it's empty.
+	LinkedDevicesDir string
+
+	// Directories that are searched, besides the one a file is
+	// saved to, for files with the content a sender offers. If one
+	// is found, the transfer is skipped. Their content IDs are kept
+	// in the content index of the profile.
+	ContentDirs []string
+
+	// How the offered file is created from a file with the same
+	// content under another name: copy, link (a hard link, or a
+	// copy if that isn't possible) or none. Hard links share the
+	// data, so changing one file changes the other. copy is used
+	// if it's empty.
+	ExistingContent string
+
+	Path    string `json:"-"`
+	Exists  bool   `json:"-"`
+	profile string
 }
 
-func LoadSettings() (*Settings, error) {
-	path, err := appXdg.ConfigFile(settingsFile)
+func LoadSettings(profile string) (*Settings, error) {
+	path, err := appXdg.ConfigFile(profileFile(profile, settingsFilename))
 	if err != nil {
 		return nil, err
 	}
 
-	settings := &Settings{Path: path}
+	settings := &Settings{Path: path, profile: profile}
 	data, err := appIoutil.ReadFile(path)
 	if err == nil {
 		err = json.Unmarshal(data, &settings)
@@ -33,7 +110,7 @@ func LoadSettings() (*Settings, error) {
 
 func (s *Settings) Save() error {
 	// A file that is executable by the owner and read-only for everyone else would be -rwxr--r--, represented as 0744
-	err := save(settingsFile, s, 0744)
+	err := save(profileFile(s.profile, settingsFilename), s, 0744)
 	if err == nil {
 		s.Exists = true
 	}
@@ -37,7 +37,7 @@ func TestLoadSettings_returnsErrorOfConfigFile(t *testing.T) {
 		Return("", expectedErr)
 
 	appXdg = m
-	settings, err := LoadSettings()
+	settings, err := LoadSettings("")
 	assert.Nil(t, settings)
 	assert.Equal(t, expectedErr, err)
 }
@@ -63,7 +63,7 @@ func TestLoadSettings_returnsErrorOfReadFile(t *testing.T) {
 		ReadFile(gomock.Eq("path")).
 		Return(nil, expectedErr)
 
-	settings, err := LoadSettings()
+	settings, err := LoadSettings("")
 	assert.Nil(t, settings)
 	assert.Equal(t, expectedErr, err)
 }
@@ -89,7 +89,7 @@ func TestLoadSettings_returnsErrorOnUnparsableData(t *testing.T) {
 		ReadFile(gomock.Eq("path")).
 		Return(data, nil)
 
-	settings, err := LoadSettings()
+	settings, err := LoadSettings("")
 	assert.Nil(t, settings)
 	assert.NotNil(t, err)
 }
@@ -114,7 +114,7 @@ func TestLoadSettings_returnsEmptySettingsIfFileDoesNotExist(t *testing.T) {
 		ReadFile(gomock.Eq("path")).
 		Return(nil, os.ErrNotExist)
 
-	settings, err := LoadSettings()
+	settings, err := LoadSettings("")
 	require.NoError(t, err)
 	assert.False(t, settings.Exists)
 	assert.Equal(t, "path", settings.Path)
@@ -141,7 +141,7 @@ func TestLoadSettings_happyPath(t *testing.T) {
 		ReadFile(gomock.Eq("path")).
 		Return(data, nil)
 
-	settings, err := LoadSettings()
+	settings, err := LoadSettings("")
 	require.NoError(t, err)
 	assert.True(t, settings.Exists)
 	assert.Equal(t, "path", settings.Path)
@@ -0,0 +1,130 @@
+package contacts
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:    "contacts",
+	Usage:   "Manages the address book of known peers.",
+	Aliases: []string{"c"},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "Lists all contacts in the address book.",
+			Action: ListAction,
+		},
+		{
+			Name:      "add",
+			Usage:     "Adds a peer to the address book or updates it.",
+			ArgsUsage: "NAME PEER_ID [MULTIADDR...]",
+			Action:    AddAction,
+		},
+		{
+			Name:      "remove",
+			Usage:     "Removes a peer from the address book.",
+			ArgsUsage: "NAME|PEER_ID",
+			Action:    RemoveAction,
+		},
+	},
+}
+
+// ListAction prints all contacts of the address book.
+func ListAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if len(conf.AddressBook.Contacts) == 0 {
+		log.Infoln("Your address book is empty")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, contact := range conf.AddressBook.Contacts {
+		verified := ""
+		if contact.Verified {
+			verified = "verified"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", contact.Name, contact.PeerID, verified)
+		for _, addr := range contact.Addrs {
+			fmt.Fprintf(tw, "\t  %s\n", addr)
+		}
+	}
+	return tw.Flush()
+}
+
+// AddAction adds the contact given by the arguments to the address book.
+func AddAction(c *cli.Context) error {
+	if c.Args().Len() < 2 {
+		return fmt.Errorf("please specify the name and the peer ID of the contact")
+	}
+
+	peerID, err := peer.Decode(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	contact := &config.Contact{
+		Name:   c.Args().First(),
+		PeerID: peerID.String(),
+	}
+
+	for _, arg := range c.Args().Slice()[2:] {
+		if _, err = ma.NewMultiaddr(arg); err != nil {
+			return err
+		}
+		contact.Addrs = append(contact.Addrs, arg)
+	}
+
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if err = conf.AddressBook.Add(contact); err != nil {
+		return err
+	}
+
+	if err = conf.AddressBook.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Saved contact %s\n", contact.Name)
+	return nil
+}
+
+// RemoveAction removes the contact given as the first argument.
+func RemoveAction(c *cli.Context) error {
+	nameOrID := c.Args().First()
+	if nameOrID == "" {
+		return fmt.Errorf("please specify the name or peer ID of the contact")
+	}
+
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if !conf.AddressBook.Remove(nameOrID) {
+		return fmt.Errorf("contact %q not found", nameOrID)
+	}
+
+	if err = conf.AddressBook.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Removed contact %s\n", nameOrID)
+	return nil
+}
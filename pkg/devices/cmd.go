@@ -0,0 +1,125 @@
+package devices
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "devices",
+	Usage:  "Links your personal devices, so they accept files from each other without asking.",
+	Action: ShowAction,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "link",
+			Usage:     "Signs the statement that a device belongs to you.",
+			ArgsUsage: "PEER_ID",
+			Action:    LinkAction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Write the device link to `FILE` instead of printing it.",
+				},
+			},
+		},
+	},
+	Description: `Pick one of your devices as the owner and run p2p devices link with the peer ID of each of
+your other devices on it. Copy the resulting file to the device and put its path into the
+DeviceLink setting there. Devices with the same owner, including the owner itself, accept
+files from each other without asking and save them into the directory of the
+LinkedDevicesDir setting.
+
+To stop trusting a lost device, block it with p2p peers block on your other devices.`,
+}
+
+// ShowAction prints the owner of this device.
+func ShowAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if conf.Settings.DeviceLink == "" {
+		log.Infoln("This device isn't linked to another one. It can link your other devices.")
+		return nil
+	}
+
+	link, err := node.LoadDeviceLink(conf.Settings.DeviceLink)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("This device is linked to %s\n", link.Owner)
+	return nil
+}
+
+// LinkAction signs the link of the device given by the argument.
+func LinkAction(c *cli.Context) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("please specify the peer ID of the device to link")
+	}
+
+	device, err := peer.Decode(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	// The links of all devices need to be signed by the same owner.
+	if conf.Settings.DeviceLink != "" {
+		link, err := node.LoadDeviceLink(conf.Settings.DeviceLink)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("this device is linked to %s, please link new devices there", link.Owner)
+	}
+
+	if !conf.Identity.IsInitialized() {
+		return fmt.Errorf("this device has no identity yet, please run p2p receive or p2p send once")
+	}
+
+	key, err := conf.Identity.PrivateKey()
+	if err != nil {
+		return err
+	}
+
+	link, err := node.LinkDevice(key, device)
+	if err != nil {
+		return err
+	}
+
+	data, err := node.EncodeDeviceLink(link)
+	if err != nil {
+		return err
+	}
+
+	if path := c.String("output"); path == "" {
+		fmt.Print(string(data))
+	} else if err = os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	// Only owners check their peers for links.
+	if !conf.Settings.OwnsDevices {
+		conf.Settings.OwnsDevices = true
+		if err = conf.Settings.Save(); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Linked device %s to this one\n", device)
+	return nil
+}
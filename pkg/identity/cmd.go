@@ -0,0 +1,224 @@
+package identity
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "identity",
+	Usage:  "Manages the key pair that identifies you to other peers.",
+	Action: ShowAction,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "backup",
+			Usage:  "Prints your private key as words that you can write down.",
+			Action: BackupAction,
+		},
+		{
+			Name:      "restore",
+			Usage:     "Restores your private key from the words of p2p identity backup.",
+			ArgsUsage: "[WORD...]",
+			Action:    RestoreAction,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Replace an existing identity with another one.",
+				},
+			},
+		},
+		{
+			Name:   "rotate",
+			Usage:  "Replaces your key pair with a new one that your old key vouches for.",
+			Action: RotateAction,
+		},
+	},
+	Description: `Your peer ID is derived from your key pair. When you rotate it, the old key signs a statement
+that vouches for the new one. You present it to every peer you get in touch with afterwards,
+so peers that have you in their address book move over to the new peer ID and keep trusting
+you, while they block the old one.
+
+p2p identity backup prints your private key as 25 words of the BIP39 word list including
+a checksum. Keep them somewhere safe. p2p identity restore reads them from the arguments
+or, to keep them out of your shell history, from the standard input and rebuilds your
+identity, so a reinstalled machine keeps its peer ID and everyone's trust in it.`,
+}
+
+// ShowAction prints the peer ID of the identity.
+func ShowAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if !conf.Identity.IsInitialized() {
+		log.Infoln("You don't have an identity yet. It's created when you first run p2p receive or p2p send.")
+		return nil
+	}
+
+	key, err := conf.Identity.PrivateKey()
+	if err != nil {
+		return err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
+
+// BackupAction prints the mnemonic of the identity.
+func BackupAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if !conf.Identity.IsInitialized() {
+		return fmt.Errorf("you don't have an identity to back up yet")
+	}
+
+	words, err := conf.Identity.Mnemonic()
+	if err != nil {
+		return err
+	}
+
+	log.Infoln("Anyone with these words can act as you. Keep them safe:")
+	for i := 0; i < len(words); i += 5 {
+		fmt.Printf("%2d. %s\n", i+1, strings.Join(words[i:i+5], " "))
+	}
+	return nil
+}
+
+// RestoreAction replaces the identity with the one of the given mnemonic.
+func RestoreAction(c *cli.Context) error {
+	words := c.Args().Slice()
+	if len(words) == 0 {
+		log.Infoln("Enter the words of your backup:")
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		words = mnemonicFields(string(data))
+	}
+
+	key, err := config.DecodeMnemonic(words)
+	if err != nil {
+		return err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if conf.Identity.IsInitialized() {
+		current, err := conf.Identity.PrivateKey()
+		if err != nil {
+			return err
+		}
+
+		if current.Equals(key) {
+			log.Infof("Your identity already is %s\n", id)
+			return nil
+		} else if !c.Bool("force") {
+			return fmt.Errorf("you already have another identity, use --force to replace it")
+		}
+
+		// The rotations vouch for the replaced key only.
+		conf.Identity.Rotations = nil
+	}
+
+	if err = conf.Identity.SetPrivateKey(key); err != nil {
+		return err
+	}
+
+	if err = conf.Identity.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Restored your identity %s\n", id)
+	return nil
+}
+
+// mnemonicFields splits the given text into words and drops the line
+// numbers that p2p identity backup prints.
+func mnemonicFields(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		if strings.HasSuffix(field, ".") {
+			if _, err := strconv.Atoi(strings.TrimSuffix(field, ".")); err == nil {
+				continue
+			}
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+// RotateAction replaces the key pair of the identity.
+func RotateAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if !conf.Identity.IsInitialized() {
+		return fmt.Errorf("you don't have an identity to rotate yet")
+	}
+
+	oldKey, err := conf.Identity.PrivateKey()
+	if err != nil {
+		return err
+	}
+
+	if err = conf.Identity.GenerateKeyPair(); err != nil {
+		return err
+	}
+
+	newKey, err := conf.Identity.PrivateKey()
+	if err != nil {
+		return err
+	}
+
+	rotation, err := node.RotateKey(oldKey, newKey)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := node.EncodeRotation(rotation)
+	if err != nil {
+		return err
+	}
+	conf.Identity.Rotations = append(conf.Identity.Rotations, encoded)
+
+	if err = conf.Identity.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Replaced your identity %s with %s\n", rotation.OldPeerId, rotation.NewPeerId)
+	log.Infoln("Your contacts move over to it the next time you get in touch with them.")
+	if conf.Settings.Certificate != "" || conf.Settings.DeviceLink != "" {
+		log.Infoln("Your certificate and device link were issued for the old identity, please get new ones.")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+)
+
+// DialableAddrs returns the addresses of this node including the
+// /p2p/ component, so they can be used to connect directly.
+func (n *Node) DialableAddrs() ([]ma.Multiaddr, error) {
+	return peer.AddrInfoToP2pAddrs(&peer.AddrInfo{
+		ID:    n.ID(),
+		Addrs: n.Addrs(),
+	})
+}
+
+// ResolvePeer converts the given multiaddress or the name or peer ID
+// of a contact in the given address book into the address information
+// needed to connect to the peer directly.
+func ResolvePeer(ab *config.AddressBook, s string) (peer.AddrInfo, error) {
+	if addr, err := ma.NewMultiaddr(s); err == nil {
+		pi, err := peer.AddrInfoFromP2pAddr(addr)
+		if err != nil {
+			return peer.AddrInfo{}, fmt.Errorf("address %s doesn't contain a peer ID: %w", s, err)
+		}
+		return *pi, nil
+	}
+
+	if ab == nil {
+		return peer.AddrInfo{}, fmt.Errorf("%q is neither a multiaddress nor a contact", s)
+	}
+
+	contact, found := ab.Lookup(s)
+	if !found {
+		return peer.AddrInfo{}, fmt.Errorf("%q is neither a multiaddress nor a contact", s)
+	}
+
+	return ContactAddrInfo(contact)
+}
+
+// ContactAddrInfo converts the addresses of the given contact into
+// address information. The addresses may omit the /p2p/ component.
+func ContactAddrInfo(c *config.Contact) (peer.AddrInfo, error) {
+	peerID, err := peer.Decode(c.PeerID)
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+
+	pi := peer.AddrInfo{ID: peerID}
+	for _, s := range c.Addrs {
+		addr, err := ma.NewMultiaddr(s)
+		if err != nil {
+			return peer.AddrInfo{}, err
+		}
+
+		// Strip the /p2p/ component if present.
+		transport, id := peer.SplitAddr(addr)
+		if id != "" && id != peerID {
+			return peer.AddrInfo{}, fmt.Errorf("address %s of contact %s belongs to another peer", s, c.Name)
+		}
+		pi.Addrs = append(pi.Addrs, transport)
+	}
+
+	if len(pi.Addrs) == 0 {
+		return peer.AddrInfo{}, fmt.Errorf("contact %s has no addresses", c.Name)
+	}
+
+	return pi, nil
+}
+
+// SplitPeerPath splits the given PEER:path argument into the peer,
+// which is a multiaddress or a contact, and the path. Multiaddresses
+// may contain colons themselves, so only colons after their /p2p/
+// component count.
+func SplitPeerPath(s string) (string, string) {
+	start := 0
+	if i := strings.LastIndex(s, "/p2p/"); i >= 0 {
+		start = i
+	}
+	if i := strings.Index(s[start:], ":"); i >= 0 {
+		return s[:start+i], s[start+i+1:]
+	}
+	return s, ""
+}
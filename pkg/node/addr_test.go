@@ -0,0 +1,87 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+)
+
+const testPeerID = "16Uiu2HAm4Ja1EXvLK7f1n7hVxvbVWTxZTqdji9LeiA2sRcYCZJEh"
+
+func TestResolvePeer_parsesMultiaddr(t *testing.T) {
+	pi, err := ResolvePeer(nil, "/ip4/10.0.3.7/tcp/44044/p2p/"+testPeerID)
+	require.NoError(t, err)
+
+	assert.Equal(t, testPeerID, pi.ID.String())
+	assert.Equal(t, ma.StringCast("/ip4/10.0.3.7/tcp/44044"), pi.Addrs[0])
+}
+
+func TestResolvePeer_rejectsMultiaddrWithoutPeerID(t *testing.T) {
+	_, err := ResolvePeer(nil, "/ip4/10.0.3.7/tcp/44044")
+	assert.Error(t, err)
+}
+
+func TestResolvePeer_looksUpContact(t *testing.T) {
+	ab := &config.AddressBook{}
+	require.NoError(t, ab.Add(&config.Contact{
+		Name:   "alice",
+		PeerID: testPeerID,
+		Addrs:  []string{"/ip4/10.0.3.7/tcp/44044", "/ip4/10.0.3.8/tcp/44044/p2p/" + testPeerID},
+	}))
+
+	pi, err := ResolvePeer(ab, "alice")
+	require.NoError(t, err)
+
+	assert.Equal(t, testPeerID, pi.ID.String())
+	assert.Equal(t, []ma.Multiaddr{
+		ma.StringCast("/ip4/10.0.3.7/tcp/44044"),
+		ma.StringCast("/ip4/10.0.3.8/tcp/44044"),
+	}, pi.Addrs)
+}
+
+func TestResolvePeer_returnsErrorForUnknownContact(t *testing.T) {
+	_, err := ResolvePeer(&config.AddressBook{}, "bob")
+	assert.Error(t, err)
+}
+
+func TestContactAddrInfo_rejectsAddressOfOtherPeer(t *testing.T) {
+	n := mockNode(t)
+	_, err := ContactAddrInfo(&config.Contact{
+		Name:   "alice",
+		PeerID: testPeerID,
+		Addrs:  []string{"/ip4/10.0.3.7/tcp/44044/p2p/" + n.ID().String()},
+	})
+	assert.Error(t, err)
+}
+
+func TestNode_DialableAddrs_containsPeerID(t *testing.T) {
+	n := mockNode(t)
+	addrs, err := n.DialableAddrs()
+	require.NoError(t, err)
+
+	for _, addr := range addrs {
+		_, id := peer.SplitAddr(addr)
+		assert.Equal(t, n.ID(), id)
+	}
+}
+
+func TestSplitPeerPath(t *testing.T) {
+	tests := []struct {
+		in, peer, path string
+	}{
+		{"alice", "alice", ""},
+		{"alice:reports/q1.pdf", "alice", "reports/q1.pdf"},
+		{"/ip4/10.0.3.7/tcp/44048/p2p/" + testPeerID, "/ip4/10.0.3.7/tcp/44048/p2p/" + testPeerID, ""},
+		{"/ip6/fe80::1/tcp/44048/p2p/" + testPeerID + ":a:b", "/ip6/fe80::1/tcp/44048/p2p/" + testPeerID, "a:b"},
+	}
+	for _, tt := range tests {
+		target, path := SplitPeerPath(tt.in)
+		assert.Equal(t, tt.peer, target, tt.in)
+		assert.Equal(t, tt.path, path, tt.in)
+	}
+}
@@ -0,0 +1,338 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// The time between two beacons of this node.
+var broadcastInterval = 5 * time.Second
+
+// The time a peer stays in the list after its last beacon.
+var broadcastExpiry = 3 * broadcastInterval
+
+// Beacons with a timestamp that deviates more than this from the
+// local time are dropped, so they can't be replayed later on.
+var broadcastMaxAge = time.Minute
+
+// The maximum size of a beacon that is accepted.
+const broadcastMaxSize = 8192
+
+// BroadcastDiscoverer finds peers in networks that drop multicast
+// but pass broadcast traffic. It periodically broadcasts a signed
+// beacon with the peer ID, addresses and mDNS service name of the
+// node and listens for the beacons of others.
+type BroadcastDiscoverer struct {
+	peerEvents
+	node  *Node
+	port  int
+	peers *sync.Map
+
+	// The addresses beacons are sent to. If empty, the broadcast
+	// addresses of all network interfaces are used.
+	targets []*net.UDPAddr
+
+	lk     sync.Mutex // protects conn and cancel
+	conn   net.PacketConn
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBroadcastDiscoverer creates a BroadcastDiscoverer that sends
+// and listens for beacons on the given UDP port.
+func NewBroadcastDiscoverer(node *Node, port int) *BroadcastDiscoverer {
+	return &BroadcastDiscoverer{
+		node:  node,
+		port:  port,
+		peers: &sync.Map{},
+	}
+}
+
+// Start listens for beacons and starts announcing the node in the
+// background if it advertises an mDNS service.
+func (b *BroadcastDiscoverer) Start(ctx context.Context) error {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if b.cancel != nil {
+		return nil
+	}
+
+	lc := net.ListenConfig{Control: controlBroadcastSocket}
+	conn, err := lc.ListenPacket(ctx, "udp4", fmt.Sprintf(":%d", b.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for broadcast beacons: %w", err)
+	}
+	b.conn = conn
+
+	beaconCtx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.listen(conn)
+	}()
+
+	if b.node.MdnsAdvertise == "" {
+		return nil
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			if err := b.announce(conn); err != nil {
+				log.Infoln(err)
+			}
+
+			t := appTime.NewTimer(broadcastInterval)
+			select {
+			case <-beaconCtx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops announcing the node and listening for beacons.
+func (b *BroadcastDiscoverer) Stop() error {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if b.cancel == nil {
+		return nil
+	}
+
+	b.cancel()
+	err := b.conn.Close()
+	b.wg.Wait()
+	b.cancel = nil
+	b.conn = nil
+
+	b.peers.Range(func(key, value interface{}) bool {
+		p := value.(*announcedPeer)
+		p.timer.Stop()
+		b.peers.Delete(key)
+		b.emit(PeerEvent{Type: PeerLeft, Peer: p.pi})
+		return true
+	})
+
+	return err
+}
+
+// PeersList returns a sorted list of address information
+// structs. Sorting order is based on the peer ID.
+func (b *BroadcastDiscoverer) PeersList() []peer.AddrInfo {
+	peers := []peer.AddrInfo{}
+	b.peers.Range(func(key, value interface{}) bool {
+		peers = append(peers, value.(*announcedPeer).pi)
+		return true
+	})
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+
+	return peers
+}
+
+// PeerInfo returns the stored information about the given peer.
+func (b *BroadcastDiscoverer) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	p, ok := b.peers.Load(peerID)
+	if !ok {
+		return nil, false
+	}
+	return p.(*announcedPeer).PeerInfo, true
+}
+
+// Service returns the mDNS service name the given peer announced
+// in its beacon.
+func (b *BroadcastDiscoverer) Service(peerID peer.ID) (string, bool) {
+	p, ok := b.peers.Load(peerID)
+	if !ok {
+		return "", false
+	}
+	return p.(*announcedPeer).service, true
+}
+
+// announce sends a signed beacon to all target addresses.
+func (b *BroadcastDiscoverer) announce(conn net.PacketConn) error {
+	beacon := p2p.NewBroadcastBeacon(b.node.serviceName(b.node.MdnsAdvertise))
+	for _, addr := range b.node.Addrs() {
+		beacon.Addrs = append(beacon.Addrs, addr.Bytes())
+	}
+
+	data, err := b.node.Marshal(beacon)
+	if err != nil {
+		return err
+	}
+
+	targets := b.targets
+	if len(targets) == 0 {
+		targets = broadcastAddrs(b.port)
+	}
+
+	for _, target := range targets {
+		if _, err = conn.WriteTo(data, target); err != nil {
+			return fmt.Errorf("failed to send beacon to %s: %w", target, err)
+		}
+	}
+
+	return nil
+}
+
+// listen handles incoming beacons until the connection is closed.
+func (b *BroadcastDiscoverer) listen(conn net.PacketConn) {
+	buf := make([]byte, broadcastMaxSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+		b.handleBeacon(buf[:n])
+	}
+}
+
+// handleBeacon verifies the given beacon and adds the announced
+// peer to the list if it offers a service the node browses for.
+func (b *BroadcastDiscoverer) handleBeacon(data []byte) {
+	beacon := &p2p.BroadcastBeacon{}
+	if err := b.node.Unmarshal(data, beacon); err != nil {
+		return
+	}
+
+	peerID, err := beacon.PeerID()
+	if err != nil || peerID == b.node.ID() {
+		return
+	}
+
+	age := appTime.Now().Sub(time.Unix(beacon.GetHeader().Timestamp, 0))
+	if age > broadcastMaxAge || age < -broadcastMaxAge {
+		return
+	}
+
+	service, found := b.browsedService(beacon.Service)
+	if !found {
+		return
+	}
+
+	pi := peer.AddrInfo{ID: peerID}
+	for _, bytes := range beacon.Addrs {
+		if addr, err := ma.NewMultiaddrBytes(bytes); err == nil {
+			pi.Addrs = append(pi.Addrs, addr)
+		}
+	}
+	if len(pi.Addrs) == 0 {
+		return
+	}
+
+	if savedPeer, ok := b.peers.Load(peerID); ok {
+		savedPeer.(*announcedPeer).timer.Reset(broadcastExpiry)
+		return
+	}
+
+	t := appTime.AfterFunc(broadcastExpiry, func() {
+		b.peers.Delete(peerID)
+		b.emit(PeerEvent{Type: PeerLeft, Peer: pi})
+	})
+	p := &announcedPeer{PeerInfo: NewPeerInfo(pi, SourceBroadcast), service: service, timer: t}
+	b.peers.Store(peerID, p)
+	b.emit(PeerEvent{Type: PeerJoined, Peer: pi})
+	if b.node.InfoProtocol != nil {
+		go b.updatePeerInfo(p.PeerInfo)
+	}
+}
+
+// browsedService returns the service the node looks for that is
+// announced under the given name in the swarm of the node.
+func (b *BroadcastDiscoverer) browsedService(name string) (string, bool) {
+	for _, s := range b.node.MdnsBrowse {
+		if b.node.serviceName(s) == name {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// broadcastAddrs returns the IPv4 broadcast addresses of all network
+// interfaces that are up. It falls back to the limited broadcast
+// address if none is found.
+func broadcastAddrs(port int) []*net.UDPAddr {
+	targets := []*net.UDPAddr{}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return []*net.UDPAddr{{IP: net.IPv4bcast, Port: port}}
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+
+			ip := ipNet.IP.To4()
+			mask := ipNet.Mask
+			if len(mask) == net.IPv6len {
+				mask = mask[12:]
+			}
+
+			bcast := make(net.IP, net.IPv4len)
+			for i := range ip {
+				bcast[i] = ip[i] | ^mask[i]
+			}
+			targets = append(targets, &net.UDPAddr{IP: bcast, Port: port})
+		}
+	}
+
+	if len(targets) == 0 {
+		targets = append(targets, &net.UDPAddr{IP: net.IPv4bcast, Port: port})
+	}
+
+	return targets
+}
+
+// UseBroadcast creates a BroadcastDiscoverer for the given port and
+// adds it to the node's Discovery. Receivers that don't start the
+// Discovery can start the returned discoverer to announce themselves.
+func (n *Node) UseBroadcast(port int) *BroadcastDiscoverer {
+	b := NewBroadcastDiscoverer(n, port)
+	n.Discovery.Add(b)
+	return b
+}
+
+// updatePeerInfo queries the information of the given peer and announces
+// the update to the subscribers.
+func (b *BroadcastDiscoverer) updatePeerInfo(p *PeerInfo) {
+	if b.node.fillPeerInfo(p) {
+		b.emit(PeerEvent{Type: PeerUpdated, Peer: p.pi})
+	}
+}
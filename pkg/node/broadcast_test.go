@@ -0,0 +1,88 @@
+package node
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestBroadcastDiscoverer_findsPeerViaBeacon(t *testing.T) {
+	defer func(interval time.Duration) { broadcastInterval = interval }(broadcastInterval)
+	broadcastInterval = 50 * time.Millisecond
+
+	// The sender only listens, so it can use a random port.
+	sender := localNode(t, "sender")
+	sender.SetMdnsServices("", commons.MdnsServiceReceive)
+	bs := sender.UseBroadcast(0)
+	require.NoError(t, sender.Discovery.Start(context.Background()))
+	defer sender.Discovery.Stop()
+
+	receiver := localNode(t, "receiver")
+	receiver.SetMdnsServices(commons.MdnsServiceReceive)
+	br := NewBroadcastDiscoverer(receiver, 0)
+	port := bs.conn.LocalAddr().(*net.UDPAddr).Port
+	br.targets = []*net.UDPAddr{{IP: net.IPv4(127, 0, 0, 1), Port: port}}
+	require.NoError(t, br.Start(context.Background()))
+
+	assert.Eventually(t, func() bool {
+		p, found := sender.Discovery.PeerInfo(receiver.ID())
+		return found && p.Info() != nil && p.Info().Nickname == "receiver"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	p, _ := sender.Discovery.PeerInfo(receiver.ID())
+	assert.Equal(t, SourceBroadcast, p.Source())
+	peers := sender.Discovery.PeersWithService(commons.MdnsServiceReceive)
+	require.Len(t, peers, 1)
+	assert.Equal(t, receiver.ID(), peers[0].ID)
+
+	require.NoError(t, br.Stop())
+}
+
+func TestBroadcastDiscoverer_handleBeacon(t *testing.T) {
+	receiver := localNode(t, "receiver")
+	receiver.SetMdnsServices(commons.MdnsServiceReceive)
+
+	sender := localNode(t, "sender")
+	sender.SetMdnsServices("", commons.MdnsServiceReceive)
+	b := NewBroadcastDiscoverer(sender, 0)
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	beacon := func(service string) []byte {
+		msg := p2p.NewBroadcastBeacon(service)
+		for _, addr := range receiver.Addrs() {
+			msg.Addrs = append(msg.Addrs, addr.Bytes())
+		}
+		data, err := receiver.Marshal(msg)
+		require.NoError(t, err)
+		return data
+	}
+
+	// Beacons of services the node doesn't browse are ignored.
+	b.handleBeacon(beacon(commons.MdnsServiceSend))
+	assert.Empty(t, b.PeersList())
+
+	// Tampered beacons are ignored.
+	data := beacon(commons.MdnsServiceReceive)
+	data[len(data)-1] ^= 0xff
+	b.handleBeacon(data)
+	assert.Empty(t, b.PeersList())
+
+	// Own beacons are ignored.
+	ownBeacon, err := sender.Marshal(p2p.NewBroadcastBeacon(commons.MdnsServiceReceive))
+	require.NoError(t, err)
+	b.handleBeacon(ownBeacon)
+	assert.Empty(t, b.PeersList())
+
+	b.handleBeacon(beacon(commons.MdnsServiceReceive))
+	require.Len(t, b.PeersList(), 1)
+	assert.Equal(t, receiver.ID(), b.PeersList()[0].ID)
+	assert.Equal(t, PeerJoined, (<-events).Type)
+}
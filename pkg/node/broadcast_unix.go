@@ -0,0 +1,26 @@
+//go:build unix
+
+package node
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// controlBroadcastSocket allows several nodes on the same machine to
+// listen on the broadcast port and permits sending broadcasts.
+func controlBroadcastSocket(network, address string, c syscall.RawConn) error {
+	var err error
+	cerr := c.Control(func(fd uintptr) {
+		for _, opt := range []int{unix.SO_REUSEADDR, unix.SO_REUSEPORT, unix.SO_BROADCAST} {
+			if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, opt, 1); err != nil {
+				return
+			}
+		}
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
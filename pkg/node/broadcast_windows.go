@@ -0,0 +1,24 @@
+//go:build windows
+
+package node
+
+import (
+	"syscall"
+)
+
+// controlBroadcastSocket allows several nodes on the same machine to
+// listen on the broadcast port and permits sending broadcasts.
+func controlBroadcastSocket(network, address string, c syscall.RawConn) error {
+	var err error
+	cerr := c.Control(func(fd uintptr) {
+		for _, opt := range []int{syscall.SO_REUSEADDR, syscall.SO_BROADCAST} {
+			if err = syscall.SetsockoptInt(syscall.Handle(fd), syscall.SOL_SOCKET, opt, 1); err != nil {
+				return
+			}
+		}
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,333 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/protobuf/proto"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// ErrNotCertified is returned by PeerCertificate if the peer
+// doesn't present a certificate or no authority is trusted.
+var ErrNotCertified = fmt.Errorf("peer isn't certified")
+
+// GenerateCAKey creates the key of a new team certificate authority.
+// Its ID embeds the public key, so nodes only need the ID to trust it.
+func GenerateCAKey() (crypto.PrivKey, peer.ID, error) {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, id, nil
+}
+
+// IssueCertificate signs a certificate that binds the given peer ID to
+// the given name and role. It's valid from now on for the given duration.
+func IssueCertificate(key crypto.PrivKey, peerID peer.ID, name string, role string, validity time.Duration) (*p2p.Certificate, error) {
+	issuer, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	serial := make([]byte, 8)
+	if _, err = rand.Read(serial); err != nil {
+		return nil, err
+	}
+
+	now := appTime.Now()
+	cert := &p2p.Certificate{
+		Serial:    hex.EncodeToString(serial),
+		PeerId:    peerID.String(),
+		Name:      name,
+		Role:      role,
+		NotBefore: now.Unix(),
+		NotAfter:  now.Add(validity).Unix(),
+		Issuer:    issuer.String(),
+	}
+
+	if cert.Signature, err = signStatement(key, domainCertificate, cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// SignRevocationList signs the list of the given revoked serials.
+func SignRevocationList(key crypto.PrivKey, serials []string) (*p2p.RevocationList, error) {
+	issuer, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &p2p.RevocationList{
+		Issuer:   issuer.String(),
+		IssuedAt: appTime.Now().Unix(),
+		Serials:  serials,
+	}
+
+	if list.Signature, err = signStatement(key, domainRevocationList, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// The domains of the signed statements. Statements of different types
+// can have the same encoding, so the signature covers the domain too
+// and can't be passed off as one of another type.
+const (
+	domainCertificate    = "p2p certificate"
+	domainRevocationList = "p2p revocation list"
+	domainDeviceLink     = "p2p device link"
+	domainRotation       = "p2p identity rotation"
+)
+
+// signStatement signs the given message with an empty signature field
+// in the given domain.
+func signStatement(key crypto.PrivKey, domain string, msg proto.Message) ([]byte, error) {
+	data, err := statementData(domain, msg)
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(data)
+}
+
+// verifyStatement checks the signature of the given message, which is
+// passed with an empty signature field, in the given domain against
+// the given issuer. The public key of the issuer is taken from its
+// peer ID if it isn't given.
+func verifyStatement(issuer string, pubKey []byte, domain string, msg proto.Message, signature []byte) error {
+	id, err := peer.Decode(issuer)
+	if err != nil {
+		return fmt.Errorf("invalid issuer %q: %w", issuer, err)
+	}
+
+	var key crypto.PubKey
+	if pubKey == nil {
+		key, err = id.ExtractPublicKey()
+	} else {
+		key, err = crypto.UnmarshalPublicKey(pubKey)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid issuer %q: %w", issuer, err)
+	} else if !id.MatchesPublicKey(key) {
+		return fmt.Errorf("public key doesn't match issuer %s", issuer)
+	}
+
+	data, err := statementData(domain, msg)
+	if err != nil {
+		return err
+	}
+
+	valid, err := key.Verify(data, signature)
+	if err != nil {
+		return err
+	} else if !valid {
+		return fmt.Errorf("invalid signature of issuer %s", issuer)
+	}
+	return nil
+}
+
+// statementData returns the signed bytes of the given message
+// in the given domain.
+func statementData(domain string, msg proto.Message) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return spakeTranscript([]byte(domain), data), nil
+}
+
+// EncodeCertificate converts the given certificate into text,
+// so it can be saved to a file or pasted into a chat.
+func EncodeCertificate(cert *p2p.Certificate) ([]byte, error) {
+	return encodeText(cert)
+}
+
+// LoadCertificate reads a certificate that EncodeCertificate
+// wrote to the given file.
+func LoadCertificate(path string) (*p2p.Certificate, error) {
+	cert := &p2p.Certificate{}
+	if err := loadText(path, cert); err != nil {
+		return nil, fmt.Errorf("invalid certificate %s: %w", path, err)
+	}
+	return cert, nil
+}
+
+// loadOwnCertificate reads the certificate of this node from the given
+// file and checks that it was issued for the given key.
+func loadOwnCertificate(path string, key crypto.PrivKey) (*p2p.Certificate, error) {
+	cert, err := LoadCertificate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	} else if cert.PeerId != id.String() {
+		return nil, fmt.Errorf("certificate %s was issued for peer %s, not for this one", path, cert.PeerId)
+	}
+	return cert, nil
+}
+
+// EncodeRevocationList converts the given revocation list into text.
+func EncodeRevocationList(list *p2p.RevocationList) ([]byte, error) {
+	return encodeText(list)
+}
+
+// LoadRevocationList reads a revocation list that EncodeRevocationList
+// wrote to the given file.
+func LoadRevocationList(path string) (*p2p.RevocationList, error) {
+	list := &p2p.RevocationList{}
+	if err := loadText(path, list); err != nil {
+		return nil, fmt.Errorf("invalid revocation list %s: %w", path, err)
+	}
+	return list, nil
+}
+
+func encodeText(msg proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data) + "\n"), nil
+}
+
+func loadText(path string, msg proto.Message) error {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return decodeText(text, msg)
+}
+
+func decodeText(text []byte, msg proto.Message) error {
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(text)))
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// Authorities verifies certificates against a set of trusted
+// team certificate authorities and their revocation lists.
+type Authorities struct {
+	trusted map[string]bool
+	revoked map[string]bool
+}
+
+// NewAuthorities trusts the authorities with the given IDs and loads
+// their revocation lists from the given files.
+func NewAuthorities(ids []string, revocationLists []string) (*Authorities, error) {
+	a := &Authorities{trusted: map[string]bool{}, revoked: map[string]bool{}}
+
+	for _, id := range ids {
+		issuer, err := peer.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate authority %q: %w", id, err)
+		}
+		a.trusted[issuer.String()] = true
+	}
+
+	for _, path := range revocationLists {
+		list, err := LoadRevocationList(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = a.AddRevocationList(list); err != nil {
+			return nil, fmt.Errorf("invalid revocation list %s: %w", path, err)
+		}
+	}
+
+	return a, nil
+}
+
+// AddRevocationList revokes the certificates in the given list if
+// it's signed by a trusted authority.
+func (a *Authorities) AddRevocationList(list *p2p.RevocationList) error {
+	if !a.trusted[list.Issuer] {
+		return fmt.Errorf("untrusted issuer %s", list.Issuer)
+	}
+
+	unsigned := proto.Clone(list).(*p2p.RevocationList)
+	unsigned.Signature = nil
+	if err := verifyStatement(list.Issuer, nil, domainRevocationList, unsigned, list.Signature); err != nil {
+		return err
+	}
+
+	for _, serial := range list.Serials {
+		a.revoked[list.Issuer+"/"+serial] = true
+	}
+	return nil
+}
+
+// IsEmpty returns true if no authority is trusted.
+func (a *Authorities) IsEmpty() bool {
+	return len(a.trusted) == 0
+}
+
+// Verify returns nil if the given certificate was issued for the given
+// peer by a trusted authority, is currently valid and isn't revoked.
+func (a *Authorities) Verify(cert *p2p.Certificate, peerID peer.ID) error {
+	if cert == nil {
+		return ErrNotCertified
+	} else if !a.trusted[cert.Issuer] {
+		return fmt.Errorf("certificate of untrusted issuer %s", cert.Issuer)
+	} else if cert.PeerId != peerID.String() {
+		return fmt.Errorf("certificate was issued for peer %s", cert.PeerId)
+	}
+
+	now := appTime.Now().Unix()
+	if now < cert.NotBefore || now > cert.NotAfter {
+		return fmt.Errorf("certificate %s has expired or isn't valid yet", cert.Serial)
+	} else if a.revoked[cert.Issuer+"/"+cert.Serial] {
+		return fmt.Errorf("certificate %s was revoked", cert.Serial)
+	}
+
+	unsigned := proto.Clone(cert).(*p2p.Certificate)
+	unsigned.Signature = nil
+	return verifyStatement(cert.Issuer, nil, domainCertificate, unsigned, cert.Signature)
+}
+
+// PeerCertificate returns the certificate of the given peer if it
+// presents a valid one of a trusted authority.
+func (n *Node) PeerCertificate(ctx context.Context, peerID peer.ID) (*p2p.Certificate, error) {
+	if n.authorities == nil || n.authorities.IsEmpty() {
+		return nil, ErrNotCertified
+	}
+
+	info, err := n.presentedInfo(ctx, peerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotCertified, err)
+	}
+	return n.PresentedCertificate(peerID, info)
+}
+
+// PresentedCertificate returns the certificate in the given information
+// of the given peer if it's a valid one of a trusted authority.
+func (n *Node) PresentedCertificate(peerID peer.ID, info *p2p.InfoResponse) (*p2p.Certificate, error) {
+	if n.authorities == nil || n.authorities.IsEmpty() {
+		return nil, ErrNotCertified
+	}
+
+	// Verify every time, because certificates expire.
+	if err := n.authorities.Verify(info.Certificate, peerID); err != nil {
+		return nil, err
+	}
+	return info.Certificate, nil
+}
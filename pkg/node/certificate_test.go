@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestAuthorities_Verify_acceptsValidCertificate(t *testing.T) {
+	key, id, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	cert, err := IssueCertificate(key, peer.ID("peer-id"), "alice", "admin", time.Hour)
+	require.NoError(t, err)
+
+	a, err := NewAuthorities([]string{id.String()}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, a.Verify(cert, peer.ID("peer-id")))
+}
+
+func TestAuthorities_Verify_rejectsInvalidCertificates(t *testing.T) {
+	key, id, err := GenerateCAKey()
+	require.NoError(t, err)
+	otherKey, _, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	a, err := NewAuthorities([]string{id.String()}, nil)
+	require.NoError(t, err)
+
+	cert, err := IssueCertificate(key, peer.ID("peer-id"), "alice", "", time.Hour)
+	require.NoError(t, err)
+	assert.Error(t, a.Verify(cert, peer.ID("other-peer-id")), "issued for another peer")
+
+	cert.Role = "admin"
+	assert.Error(t, a.Verify(cert, peer.ID("peer-id")), "tampered")
+
+	cert, err = IssueCertificate(otherKey, peer.ID("peer-id"), "alice", "", time.Hour)
+	require.NoError(t, err)
+	assert.Error(t, a.Verify(cert, peer.ID("peer-id")), "untrusted issuer")
+
+	cert, err = IssueCertificate(key, peer.ID("peer-id"), "alice", "", -time.Hour)
+	require.NoError(t, err)
+	assert.Error(t, a.Verify(cert, peer.ID("peer-id")), "expired")
+
+	assert.ErrorIs(t, a.Verify(nil, peer.ID("peer-id")), ErrNotCertified)
+}
+
+func TestAuthorities_Verify_rejectsRevokedCertificate(t *testing.T) {
+	key, id, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	cert, err := IssueCertificate(key, peer.ID("peer-id"), "alice", "", time.Hour)
+	require.NoError(t, err)
+
+	list, err := SignRevocationList(key, []string{cert.Serial})
+	require.NoError(t, err)
+	data, err := EncodeRevocationList(list)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "revoked")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	a, err := NewAuthorities([]string{id.String()}, []string{path})
+	require.NoError(t, err)
+	assert.Error(t, a.Verify(cert, peer.ID("peer-id")))
+}
+
+func TestAuthorities_AddRevocationList_rejectsForgedList(t *testing.T) {
+	_, id, err := GenerateCAKey()
+	require.NoError(t, err)
+	otherKey, _, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	a, err := NewAuthorities([]string{id.String()}, nil)
+	require.NoError(t, err)
+
+	list, err := SignRevocationList(otherKey, []string{"serial"})
+	require.NoError(t, err)
+	list.Issuer = id.String()
+	assert.Error(t, a.AddRevocationList(list))
+}
+
+func TestAuthorities_rejectStatementsOfOtherTypes(t *testing.T) {
+	key, id, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	a, err := NewAuthorities([]string{id.String()}, nil)
+	require.NoError(t, err)
+
+	// A signature over the same bytes, but in the domain of certificates.
+	list := &p2p.RevocationList{Issuer: id.String(), IssuedAt: time.Now().Unix(), Serials: []string{"serial"}}
+	list.Signature, err = signStatement(key, domainCertificate, list)
+	require.NoError(t, err)
+	assert.Error(t, a.AddRevocationList(list))
+
+	cert, err := IssueCertificate(key, peer.ID("peer-id"), "alice", "", time.Hour)
+	require.NoError(t, err)
+	cert.Signature = nil
+	cert.Signature, err = signStatement(key, domainRevocationList, cert)
+	require.NoError(t, err)
+	assert.Error(t, a.Verify(cert, peer.ID("peer-id")))
+}
+
+func TestLoadCertificate_readsEncodedCertificate(t *testing.T) {
+	key, _, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	cert, err := IssueCertificate(key, peer.ID("peer-id"), "alice", "", time.Hour)
+	require.NoError(t, err)
+	data, err := EncodeCertificate(cert)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "alice.cert")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	loaded, err := LoadCertificate(path)
+	require.NoError(t, err)
+	assert.Equal(t, cert.Serial, loaded.Serial)
+	assert.Equal(t, cert.Signature, loaded.Signature)
+}
+
+func TestNode_PeerCertificate_verifiesPresentedCertificate(t *testing.T) {
+	key, id, err := GenerateCAKey()
+	require.NoError(t, err)
+
+	n1, n2 := nodePair(t, nil)
+	n1.authorities, err = NewAuthorities([]string{id.String()}, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = n1.PeerCertificate(ctx, n2.ID())
+	assert.ErrorIs(t, err, ErrNotCertified)
+
+	n2.certificate, err = IssueCertificate(key, n2.ID(), "node-2", "", time.Hour)
+	require.NoError(t, err)
+	n1.infos.infos = nil
+
+	cert, err := n1.PeerCertificate(ctx, n2.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "node-2", cert.Name)
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// ContentID calculates the content ID of the data the given reader
+// returns.
+func ContentID(r io.Reader) (cid.Cid, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return cid.Cid{}, err
+	}
+	return contentIDFromHash(hasher)
+}
+
+// FileContentID calculates the content ID of the given file.
+func FileContentID(path string) (cid.Cid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	defer f.Close()
+
+	return ContentID(f)
+}
+
+func contentIDFromHash(hasher hash.Hash) (cid.Cid, error) {
+	mhash, err := mh.Encode(hasher.Sum(nil), mh.SHA2_256)
+	if err != nil {
+		return cid.Cid{}, err
+	}
+
+	return cid.NewCidV1(cid.Raw, mhash), nil
+}
+
+// ErrContentMismatch is returned by readers of NewVerifiedReader if
+// the data doesn't have the expected content ID.
+var ErrContentMismatch = errors.New("content ID mismatch")
+
+// verifiedReader hashes the data it reads and fails at the end of
+// the data if it doesn't match the expected content ID.
+type verifiedReader struct {
+	r        io.Reader
+	expected cid.Cid
+	hasher   hash.Hash
+}
+
+// NewVerifiedReader returns a reader that returns the data of the
+// given reader and an error instead of io.EOF if it doesn't have
+// the given content ID.
+func NewVerifiedReader(r io.Reader, c cid.Cid) (io.Reader, error) {
+	if c.Prefix().MhType != mh.SHA2_256 {
+		return nil, fmt.Errorf("unsupported content ID %s", c)
+	}
+	return &verifiedReader{r: r, expected: c, hasher: sha256.New()}, nil
+}
+
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+
+	actual, err := contentIDFromHash(v.hasher)
+	if err != nil {
+		return n, err
+	} else if !actual.Equals(v.expected) {
+		return n, fmt.Errorf("%w: received content %s instead of %s", ErrContentMismatch, actual, v.expected)
+	}
+	return n, io.EOF
+}
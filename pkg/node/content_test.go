@@ -0,0 +1,39 @@
+package node
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentID_isStable(t *testing.T) {
+	c1, err := ContentID(strings.NewReader("hello"))
+	require.NoError(t, err)
+	c2, err := ContentID(strings.NewReader("hello"))
+	require.NoError(t, err)
+	c3, err := ContentID(strings.NewReader("hello!"))
+	require.NoError(t, err)
+
+	assert.True(t, c1.Equals(c2))
+	assert.False(t, c1.Equals(c3))
+}
+
+func TestVerifiedReader(t *testing.T) {
+	c, err := ContentID(strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	r, err := NewVerifiedReader(strings.NewReader("hello"), c)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	r, err = NewVerifiedReader(strings.NewReader("hellO"), c)
+	require.NoError(t, err)
+	_, err = io.Copy(new(bytes.Buffer), r)
+	assert.ErrorIs(t, err, ErrContentMismatch)
+}
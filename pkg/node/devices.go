@@ -0,0 +1,138 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/protobuf/proto"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// LinkDevice signs the statement that the given device belongs to
+// the same user as the owner of the given key.
+func LinkDevice(key crypto.PrivKey, device peer.ID) (*p2p.DeviceLink, error) {
+	owner, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	} else if owner == device {
+		return nil, fmt.Errorf("a device can't be linked to itself")
+	}
+
+	pubKey, err := crypto.MarshalPublicKey(key.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+
+	link := &p2p.DeviceLink{
+		Owner:       owner.String(),
+		OwnerPubKey: pubKey,
+		Device:      device.String(),
+		IssuedAt:    appTime.Now().Unix(),
+	}
+
+	if link.Signature, err = signStatement(key, domainDeviceLink, link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// VerifyDeviceLink returns the owner of the given device if the given
+// link was issued for it and is signed by the owner.
+func VerifyDeviceLink(link *p2p.DeviceLink, device peer.ID) (peer.ID, error) {
+	if link.Device != device.String() {
+		return "", fmt.Errorf("device link was issued for peer %s", link.Device)
+	}
+
+	unsigned := proto.Clone(link).(*p2p.DeviceLink)
+	unsigned.Signature = nil
+	if err := verifyStatement(link.Owner, link.OwnerPubKey, domainDeviceLink, unsigned, link.Signature); err != nil {
+		return "", err
+	}
+
+	return peer.Decode(link.Owner)
+}
+
+// EncodeDeviceLink converts the given device link into text.
+func EncodeDeviceLink(link *p2p.DeviceLink) ([]byte, error) {
+	return encodeText(link)
+}
+
+// LoadDeviceLink reads a device link that EncodeDeviceLink wrote
+// to the given file.
+func LoadDeviceLink(path string) (*p2p.DeviceLink, error) {
+	link := &p2p.DeviceLink{}
+	if err := loadText(path, link); err != nil {
+		return nil, fmt.Errorf("invalid device link %s: %w", path, err)
+	}
+	return link, nil
+}
+
+// loadOwnDeviceLink reads the device link of this node from the given
+// file and checks that it was issued for the given key.
+func loadOwnDeviceLink(path string, key crypto.PrivKey) (*p2p.DeviceLink, error) {
+	link, err := LoadDeviceLink(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = VerifyDeviceLink(link, id); err != nil {
+		return nil, fmt.Errorf("invalid device link %s: %w", path, err)
+	}
+	return link, nil
+}
+
+// Owner returns the peer ID of the node that linked this one, or the
+// own peer ID if it isn't linked to another node.
+func (n *Node) Owner() peer.ID {
+	if n.deviceLink == nil {
+		return n.ID()
+	}
+	owner, _ := peer.Decode(n.deviceLink.Owner)
+	return owner
+}
+
+// IsLinkedDevice returns true if the given peer belongs to the same
+// user as this node, i.e. if both have the same owner.
+func (n *Node) IsLinkedDevice(ctx context.Context, peerID peer.ID) (bool, error) {
+	if peerID == n.ID() || !n.hasLinkedDevices() {
+		return false, nil
+	}
+
+	info, err := n.presentedInfo(ctx, peerID)
+	if err != nil {
+		return false, err
+	}
+	return n.PresentsLinkedDevice(peerID, info)
+}
+
+// PresentsLinkedDevice returns true if the given information of the
+// given peer shows that it belongs to the same user as this node.
+func (n *Node) PresentsLinkedDevice(peerID peer.ID, info *p2p.InfoResponse) (bool, error) {
+	if peerID == n.ID() || !n.hasLinkedDevices() {
+		return false, nil
+	}
+
+	owner := peerID
+	var err error
+	if info.DeviceLink != nil {
+		if owner, err = VerifyDeviceLink(info.DeviceLink, peerID); err != nil {
+			return false, err
+		}
+	}
+
+	return owner == n.Owner(), nil
+}
+
+// hasLinkedDevices returns true if this node is linked to an owner or
+// linked other nodes to itself, so there can be linked devices at all.
+func (n *Node) hasLinkedDevices() bool {
+	return n.deviceLink != nil || n.ownsDevices
+}
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDeviceLink_returnsOwner(t *testing.T) {
+	key, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	require.NoError(t, err)
+	owner, err := peer.IDFromPrivateKey(key)
+	require.NoError(t, err)
+
+	link, err := LinkDevice(key, peer.ID("device"))
+	require.NoError(t, err)
+
+	linkedOwner, err := VerifyDeviceLink(link, peer.ID("device"))
+	require.NoError(t, err)
+	assert.Equal(t, owner, linkedOwner)
+
+	_, err = VerifyDeviceLink(link, peer.ID("other-device"))
+	assert.Error(t, err)
+}
+
+func TestVerifyDeviceLink_rejectsForgedLink(t *testing.T) {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	victim, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	victimID, err := peer.IDFromPrivateKey(victim)
+	require.NoError(t, err)
+
+	link, err := LinkDevice(key, peer.ID("device"))
+	require.NoError(t, err)
+	link.Owner = victimID.String()
+
+	_, err = VerifyDeviceLink(link, peer.ID("device"))
+	assert.Error(t, err)
+}
+
+func TestLinkDevice_refusesOwnPeerID(t *testing.T) {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(key)
+	require.NoError(t, err)
+
+	_, err = LinkDevice(key, id)
+	assert.Error(t, err)
+}
+
+func TestNode_IsLinkedDevice(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n1.ownsDevices = true
+	ctx := context.Background()
+
+	linked, err := n1.IsLinkedDevice(ctx, n2.ID())
+	require.NoError(t, err)
+	assert.False(t, linked)
+
+	n2.deviceLink, err = LinkDevice(n1.Peerstore().PrivKey(n1.ID()), n2.ID())
+	require.NoError(t, err)
+	n1.infos.infos = nil
+
+	linked, err = n1.IsLinkedDevice(ctx, n2.ID())
+	require.NoError(t, err)
+	assert.True(t, linked, "the owner accepts its device")
+
+	linked, err = n2.IsLinkedDevice(ctx, n1.ID())
+	require.NoError(t, err)
+	assert.True(t, linked, "the device accepts its owner")
+}
+
+func TestNode_IsLinkedDevice_withoutLinks(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	require.NoError(t, n2.Close())
+
+	// Nodes without links don't ask the peer.
+	linked, err := n1.IsLinkedDevice(context.Background(), n2.ID())
+	require.NoError(t, err)
+	assert.False(t, linked)
+}
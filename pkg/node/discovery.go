@@ -0,0 +1,412 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// The sources a peer can be discovered by.
+const (
+	SourceMDNS       = "mdns"
+	SourceStatic     = "static"
+	SourceRendezvous = "rendezvous"
+	SourceBroadcast  = "broadcast"
+)
+
+// The number of events a subscription buffers before it starts
+// dropping them because the subscriber doesn't keep up.
+const eventBufferSize = 64
+
+// PeerEventType describes what happened to a discovered peer.
+type PeerEventType int
+
+const (
+	PeerJoined PeerEventType = iota
+	PeerUpdated
+	PeerLeft
+)
+
+// PeerEvent is emitted by a Discoverer when a peer joins, leaves or
+// when what is known about it changes, e.g. its advertised information.
+type PeerEvent struct {
+	Type PeerEventType
+	Peer peer.AddrInfo
+}
+
+// peerEvents distributes the events of a discoverer to all of its
+// subscribers. The zero value is ready to use.
+type peerEvents struct {
+	lk   sync.Mutex
+	subs map[chan PeerEvent]struct{}
+}
+
+// Subscribe returns a channel on which all future peer events are
+// announced and a function that cancels the subscription and closes
+// the channel. Events are dropped if the subscriber doesn't keep up.
+func (e *peerEvents) Subscribe() (<-chan PeerEvent, func()) {
+	ch := make(chan PeerEvent, eventBufferSize)
+
+	e.lk.Lock()
+	if e.subs == nil {
+		e.subs = map[chan PeerEvent]struct{}{}
+	}
+	e.subs[ch] = struct{}{}
+	e.lk.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.lk.Lock()
+			defer e.lk.Unlock()
+			delete(e.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// emit announces the given event to all subscribers without blocking.
+func (e *peerEvents) emit(ev PeerEvent) {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	for ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Discoverer is implemented by every mechanism that finds peers,
+// e.g. the MDNSProtocol.
+type Discoverer interface {
+	// Start starts looking for peers in the background.
+	Start(ctx context.Context) error
+
+	// Stop stops looking for peers and forgets the found ones.
+	Stop() error
+
+	// Subscribe announces peers that join, change or leave until
+	// the returned function is called.
+	Subscribe() (<-chan PeerEvent, func())
+
+	// PeersList returns the currently known peers sorted by their ID.
+	PeersList() []peer.AddrInfo
+
+	// PeerInfo returns what is known about the given peer.
+	PeerInfo(peerID peer.ID) (*PeerInfo, bool)
+}
+
+// PeerInfo holds the address information of a discovered peer
+// together with the information it advertises about itself.
+type PeerInfo struct {
+	pi      peer.AddrInfo
+	source  string
+	info    atomic.Pointer[p2p.InfoResponse]
+	offline atomic.Bool
+}
+
+// NewPeerInfo creates a PeerInfo for a peer that was found by the given source.
+func NewPeerInfo(pi peer.AddrInfo, source string) *PeerInfo {
+	return &PeerInfo{pi: pi, source: source}
+}
+
+// AddrInfo returns the address information of the peer.
+func (p *PeerInfo) AddrInfo() peer.AddrInfo {
+	return p.pi
+}
+
+// Source returns the name of the mechanism that found the peer.
+func (p *PeerInfo) Source() string {
+	return p.source
+}
+
+// Info returns the information the peer advertises about itself
+// or nil if it hasn't been queried successfully yet.
+func (p *PeerInfo) Info() *p2p.InfoResponse {
+	return p.info.Load()
+}
+
+// Online returns false if the peer is known but currently unreachable.
+func (p *PeerInfo) Online() bool {
+	return !p.offline.Load()
+}
+
+// SetOnline records whether the peer is currently reachable.
+func (p *PeerInfo) SetOnline(online bool) {
+	p.offline.Store(!online)
+}
+
+// SetInfo caches the information the peer advertises about itself.
+func (p *PeerInfo) SetInfo(info *p2p.InfoResponse) {
+	p.info.Store(info)
+}
+
+// Discovery merges the peers of several discoverers into one list.
+// It implements the Discoverer interface itself.
+type Discovery struct {
+	peerEvents
+	lk          sync.RWMutex
+	discoverers []Discoverer
+	unsubscribe []func()
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewDiscovery creates a Discovery that merges the given discoverers.
+func NewDiscovery(discoverers ...Discoverer) *Discovery {
+	return &Discovery{discoverers: discoverers}
+}
+
+// Add registers another discoverer. It must be called before Start.
+func (d *Discovery) Add(discoverer Discoverer) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	d.discoverers = append(d.discoverers, discoverer)
+}
+
+// Start starts all discoverers and forwards their events.
+func (d *Discovery) Start(ctx context.Context) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	if d.cancel != nil {
+		return nil
+	}
+
+	for _, discoverer := range d.discoverers {
+		if err := discoverer.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	fwdCtx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	for _, discoverer := range d.discoverers {
+		events, unsubscribe := discoverer.Subscribe()
+		d.unsubscribe = append(d.unsubscribe, unsubscribe)
+		d.wg.Add(1)
+		go d.forward(fwdCtx, events)
+	}
+
+	return nil
+}
+
+// forward passes the events of a single discoverer on until the
+// context is canceled or the subscription ends.
+func (d *Discovery) forward(ctx context.Context, events <-chan PeerEvent) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			d.emit(e)
+		}
+	}
+}
+
+// Stop stops all discoverers.
+func (d *Discovery) Stop() error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
+		d.cancel = nil
+		for _, unsubscribe := range d.unsubscribe {
+			unsubscribe()
+		}
+		d.unsubscribe = nil
+	}
+
+	var firstErr error
+	for _, discoverer := range d.discoverers {
+		if err := discoverer.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// PeersList returns the peers of all discoverers sorted by their ID.
+// The addresses of peers found by several discoverers are merged.
+func (d *Discovery) PeersList() []peer.AddrInfo {
+	d.lk.RLock()
+	defer d.lk.RUnlock()
+
+	merged := map[peer.ID]*peer.AddrInfo{}
+	for _, discoverer := range d.discoverers {
+		for _, pi := range discoverer.PeersList() {
+			existing, found := merged[pi.ID]
+			if !found {
+				cpy := pi
+				cpy.Addrs = append([]ma.Multiaddr(nil), pi.Addrs...)
+				merged[pi.ID] = &cpy
+				continue
+			}
+			for _, addr := range pi.Addrs {
+				if !containsAddr(existing.Addrs, addr) {
+					existing.Addrs = append(existing.Addrs, addr)
+				}
+			}
+		}
+	}
+
+	peers := []peer.AddrInfo{}
+	for _, pi := range merged {
+		peers = append(peers, *pi)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+
+	return peers
+}
+
+// PeerInfo returns what the discoverers know about the given peer.
+// Entries that contain the information the peer advertises are preferred.
+func (d *Discovery) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	d.lk.RLock()
+	defer d.lk.RUnlock()
+
+	var found *PeerInfo
+	for _, discoverer := range d.discoverers {
+		p, ok := discoverer.PeerInfo(peerID)
+		if !ok {
+			continue
+		}
+		if p.Info() != nil {
+			return p, true
+		}
+		if found == nil {
+			found = p
+		}
+	}
+
+	return found, found != nil
+}
+
+// serviceDiscoverer is implemented by discoverers that find peers
+// by the mDNS service name they announce.
+type serviceDiscoverer interface {
+	// Service returns the service name the given peer was found with.
+	Service(peerID peer.ID) (string, bool)
+}
+
+// PeersWithService works like PeersList but only returns the peers
+// that were found with the given mDNS service name.
+func (d *Discovery) PeersWithService(service string) []peer.AddrInfo {
+	all := d.PeersList()
+
+	d.lk.RLock()
+	defer d.lk.RUnlock()
+
+	peers := []peer.AddrInfo{}
+	for _, pi := range all {
+		for _, discoverer := range d.discoverers {
+			sd, ok := discoverer.(serviceDiscoverer)
+			if !ok {
+				continue
+			}
+			if s, found := sd.Service(pi.ID); found && s == service {
+				peers = append(peers, pi)
+				break
+			}
+		}
+	}
+	return peers
+}
+
+// PeersWithRole works like PeersList but leaves out the peers
+// that advertise a different role and those that are offline.
+// Peers that haven't answered the info request yet are kept.
+func (d *Discovery) PeersWithRole(role string) []peer.AddrInfo {
+	peers := []peer.AddrInfo{}
+	for _, pi := range d.PeersList() {
+		p, ok := d.PeerInfo(pi.ID)
+		if ok && p.Info() != nil && p.Info().Role != role {
+			continue
+		}
+		if !d.isOnline(pi.ID) {
+			continue
+		}
+		peers = append(peers, pi)
+	}
+	return peers
+}
+
+// isOnline returns true if at least one discoverer considers the
+// given peer reachable.
+func (d *Discovery) isOnline(peerID peer.ID) bool {
+	d.lk.RLock()
+	defer d.lk.RUnlock()
+
+	for _, discoverer := range d.discoverers {
+		if p, ok := discoverer.PeerInfo(peerID); ok && p.Online() {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintPeers dumps the given list of peers to the screen
+// to be selected by the user via its index.
+func (d *Discovery) PrintPeers(peers []peer.AddrInfo) {
+	for i, p := range peers {
+		fmt.Fprintf(os.Stdout, "[%d] %s\n", i, d.describePeer(p.ID))
+	}
+	fmt.Fprintln(os.Stdout)
+}
+
+// describePeer formats the peer ID together with the information
+// the peer advertises about itself if it is known.
+func (d *Discovery) describePeer(peerID peer.ID) string {
+	p, ok := d.PeerInfo(peerID)
+	if !ok {
+		return peerID.String()
+	}
+
+	desc := peerID.String()
+	if info := p.Info(); info != nil {
+		desc = fmt.Sprintf("%s (%s, %s, %s) %s", info.Nickname, info.Os, info.Version, info.Role, peerID)
+	}
+
+	// Mark peers that weren't found in the local network or can't be reached.
+	var tags []string
+	if p.Source() != SourceMDNS {
+		tags = append(tags, p.Source())
+	}
+	if !p.Online() {
+		tags = append(tags, "offline")
+	}
+	if len(tags) > 0 {
+		desc = fmt.Sprintf("%s [%s]", desc, strings.Join(tags, ", "))
+	}
+
+	return desc
+}
+
+func containsAddr(addrs []ma.Multiaddr, addr ma.Multiaddr) bool {
+	for _, a := range addrs {
+		if a.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
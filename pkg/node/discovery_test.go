@@ -0,0 +1,143 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// fakeDiscoverer is a Discoverer with a fixed set of peers.
+type fakeDiscoverer struct {
+	peerEvents
+	lk      sync.Mutex
+	peers   map[peer.ID]*PeerInfo
+	started bool
+}
+
+func newFakeDiscoverer(source string, pis ...peer.AddrInfo) *fakeDiscoverer {
+	f := &fakeDiscoverer{peers: map[peer.ID]*PeerInfo{}}
+	for _, pi := range pis {
+		f.peers[pi.ID] = NewPeerInfo(pi, source)
+	}
+	return f
+}
+
+func (f *fakeDiscoverer) Start(ctx context.Context) error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.started = true
+	return nil
+}
+
+func (f *fakeDiscoverer) Stop() error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.started = false
+	return nil
+}
+
+func (f *fakeDiscoverer) PeersList() []peer.AddrInfo {
+	peers := []peer.AddrInfo{}
+	for _, p := range f.peers {
+		peers = append(peers, p.AddrInfo())
+	}
+	return peers
+}
+
+func (f *fakeDiscoverer) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	p, ok := f.peers[peerID]
+	return p, ok
+}
+
+func TestDiscovery_PeersList_mergesDiscoverers(t *testing.T) {
+	addr1 := ma.StringCast("/ip4/10.0.0.1/tcp/44044")
+	addr2 := ma.StringCast("/ip4/10.0.0.2/tcp/44044")
+
+	d := NewDiscovery(
+		newFakeDiscoverer("a", peer.AddrInfo{ID: "peer-id-2", Addrs: []ma.Multiaddr{addr1}}),
+		newFakeDiscoverer("b",
+			peer.AddrInfo{ID: "peer-id-2", Addrs: []ma.Multiaddr{addr1, addr2}},
+			peer.AddrInfo{ID: "peer-id-1"},
+		),
+	)
+
+	peers := d.PeersList()
+	require.Len(t, peers, 2)
+	assert.Equal(t, peer.ID("peer-id-1"), peers[0].ID)
+	assert.Equal(t, peer.ID("peer-id-2"), peers[1].ID)
+	assert.Equal(t, []ma.Multiaddr{addr1, addr2}, peers[1].Addrs)
+}
+
+func TestDiscovery_PeerInfo_prefersEntryWithInfo(t *testing.T) {
+	a := newFakeDiscoverer("a", peer.AddrInfo{ID: "peer-id"})
+	b := newFakeDiscoverer("b", peer.AddrInfo{ID: "peer-id"})
+	b.peers["peer-id"].SetInfo(p2p.NewInfoResponse("alice-laptop", "linux", "v1.0.0", RoleReceiver))
+
+	d := NewDiscovery(a, b)
+	p, found := d.PeerInfo("peer-id")
+	require.True(t, found)
+	assert.Equal(t, "b", p.Source())
+	assert.Contains(t, d.describePeer("peer-id"), "alice-laptop")
+}
+
+func TestDiscovery_PeersWithRole_filtersOtherRoles(t *testing.T) {
+	receiver := peer.AddrInfo{ID: peer.ID("peer-id-1")}
+	sender := peer.AddrInfo{ID: peer.ID("peer-id-2")}
+	unknown := peer.AddrInfo{ID: peer.ID("peer-id-3")}
+
+	f := newFakeDiscoverer("fake", receiver, sender, unknown)
+	f.peers[receiver.ID].SetInfo(p2p.NewInfoResponse("receiver", "linux", "v1.0.0", RoleReceiver))
+	f.peers[sender.ID].SetInfo(p2p.NewInfoResponse("sender", "linux", "v1.0.0", RoleSender))
+
+	d := NewDiscovery(f)
+	assert.Equal(t, []peer.AddrInfo{receiver, unknown}, d.PeersWithRole(RoleReceiver))
+}
+
+func TestDiscovery_StartStop_forwardsEvents(t *testing.T) {
+	f := newFakeDiscoverer("fake")
+	d := NewDiscovery()
+	d.Add(f)
+
+	events, unsubscribe := d.Subscribe()
+	defer unsubscribe()
+
+	require.NoError(t, d.Start(context.Background()))
+	assert.True(t, f.started)
+
+	e := PeerEvent{Type: PeerJoined, Peer: peer.AddrInfo{ID: "peer-id"}}
+	f.emit(e)
+	assert.Equal(t, e, <-events)
+
+	require.NoError(t, d.Stop())
+	assert.False(t, f.started)
+}
+
+func TestPeerEvents_Subscribe(t *testing.T) {
+	var e peerEvents
+
+	events1, unsubscribe1 := e.Subscribe()
+	events2, unsubscribe2 := e.Subscribe()
+	defer unsubscribe2()
+
+	joined := PeerEvent{Type: PeerJoined, Peer: peer.AddrInfo{ID: "peer-id"}}
+	e.emit(joined)
+	assert.Equal(t, joined, <-events1)
+	assert.Equal(t, joined, <-events2)
+
+	// Cancelled subscriptions are closed and don't receive events anymore.
+	unsubscribe1()
+	unsubscribe1()
+	_, ok := <-events1
+	assert.False(t, ok)
+
+	updated := PeerEvent{Type: PeerUpdated, Peer: peer.AddrInfo{ID: "peer-id"}}
+	e.emit(updated)
+	assert.Equal(t, updated, <-events2)
+}
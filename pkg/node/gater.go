@@ -0,0 +1,134 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// ConnectionGater restricts connections to addresses in the local
+// network, i.e. private, loopback and link-local addresses. Addresses
+// in the allow list are accepted additionally, addresses in the deny
+// list are always rejected. It also rejects blocked peers.
+type ConnectionGater struct {
+	allowPublic bool
+	allow       []*net.IPNet
+	deny        []*net.IPNet
+
+	lk      sync.RWMutex // protects blocked
+	blocked map[peer.ID]time.Time
+}
+
+// NewConnectionGater creates a ConnectionGater with the given CIDR allow and deny
+// lists. If allowPublic is true, only the deny list is applied.
+func NewConnectionGater(allowPublic bool, allow []string, deny []string) (*ConnectionGater, error) {
+	g := &ConnectionGater{allowPublic: allowPublic, blocked: map[peer.ID]time.Time{}}
+
+	var err error
+	if g.allow, err = parseCIDRs(allow); err != nil {
+		return nil, err
+	}
+	if g.deny, err = parseCIDRs(deny); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Allowed returns true if connections from and to the given address
+// are permitted.
+func (g *ConnectionGater) Allowed(addr ma.Multiaddr) bool {
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		// Relayed connections don't carry the address of the peer.
+		// The connection to the relay itself has been gated already.
+		return isRelayAddr(addr)
+	}
+
+	for _, ipNet := range g.deny {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	if g.allowPublic || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+
+	for _, ipNet := range g.allow {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Block rejects connections from and to the given peer until the
+// given time. The zero time blocks the peer permanently.
+func (g *ConnectionGater) Block(peerID peer.ID, until time.Time) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	g.blocked[peerID] = until
+}
+
+// Unblock allows connections from and to the given peer again.
+func (g *ConnectionGater) Unblock(peerID peer.ID) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	delete(g.blocked, peerID)
+}
+
+// IsBlocked returns true if the given peer is currently blocked.
+func (g *ConnectionGater) IsBlocked(peerID peer.ID) bool {
+	g.lk.RLock()
+	defer g.lk.RUnlock()
+
+	until, found := g.blocked[peerID]
+	return found && (until.IsZero() || appTime.Now().Before(until))
+}
+
+// InterceptPeerDial rejects dialing blocked peers.
+func (g *ConnectionGater) InterceptPeerDial(peerID peer.ID) bool {
+	return !g.IsBlocked(peerID)
+}
+
+// InterceptAddrDial rejects dialing addresses outside the local network.
+func (g *ConnectionGater) InterceptAddrDial(_ peer.ID, addr ma.Multiaddr) bool {
+	return g.Allowed(addr)
+}
+
+// InterceptAccept rejects connections from addresses outside the local network.
+func (g *ConnectionGater) InterceptAccept(cm network.ConnMultiaddrs) bool {
+	return g.Allowed(cm.RemoteMultiaddr())
+}
+
+// InterceptSecured rejects connections of blocked peers once their
+// identity is known.
+func (g *ConnectionGater) InterceptSecured(_ network.Direction, peerID peer.ID, _ network.ConnMultiaddrs) bool {
+	return !g.IsBlocked(peerID)
+}
+
+// InterceptUpgraded allows every connection that has been accepted before.
+func (g *ConnectionGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ipNets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets, nil
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionGater_Allowed(t *testing.T) {
+	g, err := NewConnectionGater(false, []string{"203.0.113.0/24"}, []string{"192.168.66.0/24"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"/ip4/192.168.1.10/tcp/44044", true},
+		{"/ip4/10.0.3.7/udp/44044/quic-v1", true},
+		{"/ip4/127.0.0.1/tcp/44044", true},
+		{"/ip4/169.254.12.1/tcp/44044", true},
+		{"/ip6/::1/tcp/44044", true},
+		{"/ip6/fe80::1/tcp/44044", true},
+		{"/ip6/fd00::1/tcp/44044", true},
+		{"/ip4/8.8.8.8/tcp/44044", false},
+		{"/ip6/2001:4860::8888/tcp/44044", false},
+		{"/ip4/203.0.113.5/tcp/44044", true},
+		{"/ip4/192.168.66.2/tcp/44044", false},
+		{"/p2p/" + testPeerID + "/p2p-circuit", true},
+		{"/dns4/example.com/tcp/44044", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, g.Allowed(ma.StringCast(tt.addr)), tt.addr)
+	}
+}
+
+func TestConnectionGater_allowPublic(t *testing.T) {
+	g, err := NewConnectionGater(true, nil, []string{"8.8.8.0/24"})
+	require.NoError(t, err)
+
+	assert.True(t, g.Allowed(ma.StringCast("/ip4/1.1.1.1/tcp/44044")))
+	assert.False(t, g.Allowed(ma.StringCast("/ip4/8.8.8.8/tcp/44044")))
+}
+
+func TestNewConnectionGater_invalidCIDR(t *testing.T) {
+	_, err := NewConnectionGater(false, []string{"10.0.0.0"}, nil)
+	assert.Error(t, err)
+}
+
+func TestConnectionGater_Block(t *testing.T) {
+	g, err := NewConnectionGater(false, nil, nil)
+	require.NoError(t, err)
+	peerID := peer.ID("peer")
+
+	g.Block(peerID, time.Time{})
+	assert.True(t, g.IsBlocked(peerID))
+	assert.False(t, g.InterceptPeerDial(peerID))
+
+	g.Unblock(peerID)
+	assert.False(t, g.IsBlocked(peerID))
+
+	g.Block(peerID, time.Now().Add(-time.Second))
+	assert.False(t, g.IsBlocked(peerID), "expired")
+}
@@ -0,0 +1,249 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolInfo = "/p2p/info/0.1.0"
+
+// The time we wait for a newly discovered peer to answer
+// the info request.
+var infoTimeout = 3 * time.Second
+
+// The roles a node can advertise to its peers.
+const (
+	RoleSender   = "sender"
+	RoleReceiver = "receiver"
+)
+
+// InfoProtocol answers queries for a human-readable description
+// of this node and queries the same information from other peers.
+// Both sides present their information, so the answering node
+// knows who is asking.
+type InfoProtocol struct {
+	node     *Node
+	lk       sync.RWMutex
+	nickname string
+	role     string
+	filter   func(peer.ID, *p2p.InfoResponse) bool
+}
+
+// NewInfoProtocol initializes a new InfoProtocol object and
+// registers its stream handler.
+func NewInfoProtocol(node *Node, nickname string) *InfoProtocol {
+	if nickname == "" {
+		nickname, _ = os.Hostname()
+	}
+
+	i := &InfoProtocol{node: node, lk: sync.RWMutex{}, nickname: nickname}
+	node.SetStreamHandler(ProtocolInfo, i.onInfoRequest)
+	return i
+}
+
+// SetRole sets the role that is advertised to other peers.
+func (i *InfoProtocol) SetRole(role string) {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+	i.role = role
+}
+
+// SetPeerFilter restricts the peers that get an answer to their
+// info requests to the ones the given function returns true for.
+// It gets the information the peer presented with its request and
+// must not contact the peer. A nil filter answers everyone.
+func (i *InfoProtocol) SetPeerFilter(filter func(peer.ID, *p2p.InfoResponse) bool) {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+	i.filter = filter
+}
+
+// LocalInfo returns the information about this node as
+// it is sent to other peers.
+func (i *InfoProtocol) LocalInfo() *p2p.InfoResponse {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+	resp := p2p.NewInfoResponse(i.nickname, runtime.GOOS, commons.Version, i.role)
+	resp.Certificate = i.node.certificate
+	resp.DeviceLink = i.node.deviceLink
+	resp.Rotations = i.node.rotations
+	return resp
+}
+
+func (i *InfoProtocol) onInfoRequest(s network.Stream) {
+	remote := s.Conn().RemotePeer()
+
+	req := &p2p.InfoResponse{}
+	if err := i.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	// Don't accept information that another node authored.
+	if author, err := req.PeerID(); err != nil || author != remote {
+		log.Infof("Dropped info request of %s that it didn't author\n", remote)
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+	i.node.applyRotations(remote, req.Rotations)
+	i.node.infos.add(remote, req)
+
+	i.lk.RLock()
+	filter := i.filter
+	i.lk.RUnlock()
+
+	if filter != nil && !filter(remote, req) {
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+
+	defer s.Close()
+
+	if err := i.node.Send(s, i.LocalInfo()); err != nil {
+		log.Infoln(err)
+		return
+	}
+}
+
+// RequestInfo presents the information of this node to the given
+// peer and queries the information of the peer in return.
+func (i *InfoProtocol) RequestInfo(ctx context.Context, peerID peer.ID) (*p2p.InfoResponse, error) {
+
+	s, err := i.node.NewStream(withRelayedConns(ctx, "info"), peerID, ProtocolInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err = i.node.Send(s, i.LocalInfo()); err != nil {
+		return nil, err
+	}
+
+	resp := &p2p.InfoResponse{}
+	if err = i.node.Read(s, resp); err != nil {
+		return nil, err
+	}
+
+	// Don't accept information that another node authored.
+	if author, err := resp.PeerID(); err != nil || author != peerID {
+		return nil, fmt.Errorf("received info of unexpected peer %q", resp.GetHeader().GetNodeId())
+	}
+
+	// Peers announce key rotations to everyone they get in touch with.
+	i.node.applyRotations(peerID, resp.Rotations)
+
+	return resp, nil
+}
+
+// The time the information that a peer presented is cached. Peers
+// can change their certificates and device links in the meantime.
+var peerInfoTTL = 10 * time.Minute
+
+// The number of peers whose information is cached at most.
+var peerInfoMaxCount = 1024
+
+// peerInfoCache remembers the information that peers presented, either
+// in answer to an info request or with their own, so their certificates
+// and device links are only requested once in a while.
+type peerInfoCache struct {
+	lk    sync.Mutex // protects infos
+	infos map[peer.ID]*cachedInfo
+}
+
+type cachedInfo struct {
+	info    *p2p.InfoResponse
+	expires time.Time
+}
+
+// presentedInfo returns the information of the given peer, which is
+// requested once and cached afterwards.
+func (n *Node) presentedInfo(ctx context.Context, peerID peer.ID) (*p2p.InfoResponse, error) {
+	if info := n.infos.get(peerID); info != nil {
+		return info, nil
+	}
+
+	info, err := n.RequestInfo(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.infos.add(peerID, info)
+	return info, nil
+}
+
+// get returns the cached information of the given peer or nil if
+// there's none or it has expired.
+func (c *peerInfoCache) get(peerID peer.ID) *p2p.InfoResponse {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	cached, found := c.infos[peerID]
+	if !found || time.Now().After(cached.expires) {
+		return nil
+	}
+	return cached.info
+}
+
+// add stores the information the given peer presented. If the cache
+// is full, expired entries are removed first and then the one that
+// expires next.
+func (c *peerInfoCache) add(peerID peer.ID, info *p2p.InfoResponse) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.infos == nil {
+		c.infos = map[peer.ID]*cachedInfo{}
+	}
+
+	now := time.Now()
+	if _, found := c.infos[peerID]; !found && len(c.infos) >= peerInfoMaxCount {
+		var next peer.ID
+		for id, cached := range c.infos {
+			if now.After(cached.expires) {
+				delete(c.infos, id)
+			} else if next == "" || cached.expires.Before(c.infos[next].expires) {
+				next = id
+			}
+		}
+		if len(c.infos) >= peerInfoMaxCount {
+			delete(c.infos, next)
+		}
+	}
+
+	c.infos[peerID] = &cachedInfo{info: info, expires: now.Add(peerInfoTTL)}
+}
+
+// fillPeerInfo asks the newly discovered peer for its human-readable
+// information and caches the answer in the given PeerInfo. It returns
+// true if the information was stored.
+func (i *InfoProtocol) fillPeerInfo(p *PeerInfo) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), infoTimeout)
+	defer cancel()
+
+	if err := i.node.Connect(ctx, p.pi); err != nil {
+		return false
+	}
+
+	info, err := i.RequestInfo(ctx, p.pi.ID)
+	if err != nil {
+		return false
+	}
+
+	p.info.Store(info)
+	return true
+}
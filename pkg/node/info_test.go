@@ -0,0 +1,119 @@
+package node
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestNewInfoProtocol_defaultsToHostname(t *testing.T) {
+	n := mockNode(t)
+	i := NewInfoProtocol(n, "")
+
+	assert.NotEmpty(t, i.LocalInfo().Nickname)
+}
+
+func TestInfoProtocol_RequestInfo_returnsPeerInfo(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n2.SetRole(RoleReceiver)
+
+	info, err := n1.RequestInfo(context.Background(), n2.ID())
+	require.NoError(t, err)
+
+	assert.Equal(t, "receiver", info.Nickname)
+	assert.Equal(t, runtime.GOOS, info.Os)
+	assert.Equal(t, commons.Version, info.Version)
+	assert.Equal(t, RoleReceiver, info.Role)
+}
+
+func TestMDNSProtocol_HandlePeerFound_cachesPeerInfo(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n2.SetRole(RoleSender)
+	m := NewMDNSProtocol(n1)
+
+	m.HandlePeerFound(n1.Peerstore().PeerInfo(n2.ID()))
+
+	assert.Eventually(t, func() bool {
+		p, ok := m.PeerInfo(n2.ID())
+		return ok && p.Info() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	p, _ := m.PeerInfo(n2.ID())
+	assert.Equal(t, "receiver", p.Info().Nickname)
+	assert.Equal(t, RoleSender, p.Info().Role)
+	assert.Contains(t, NewDiscovery(m).describePeer(n2.ID()), "receiver")
+}
+
+func TestMDNSProtocol_HandlePeerFound_emitsUpdateWithPeerInfo(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	m := NewMDNSProtocol(n1)
+
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	pi := n1.Peerstore().PeerInfo(n2.ID())
+	m.HandlePeerFound(pi)
+
+	assert.Equal(t, PeerJoined, (<-events).Type)
+	select {
+	case e := <-events:
+		assert.Equal(t, PeerEvent{Type: PeerUpdated, Peer: pi}, e)
+	case <-time.After(time.Second):
+		t.Fatal("expected peer update after the info request")
+	}
+}
+
+func TestInfoProtocol_SetPeerFilter_getsPresentedInfo(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+
+	var presented *p2p.InfoResponse
+	n2.SetPeerFilter(func(peerID peer.ID, info *p2p.InfoResponse) bool {
+		presented = info
+		return peerID == n1.ID()
+	})
+
+	_, err := n1.RequestInfo(context.Background(), n2.ID())
+	require.NoError(t, err)
+	require.NotNil(t, presented)
+	assert.Equal(t, "sender", presented.Nickname)
+
+	// The presented information is cached, so it isn't requested again.
+	require.NoError(t, n1.Close())
+	info, err := n2.presentedInfo(context.Background(), n1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "sender", info.Nickname)
+}
+
+func TestInfoProtocol_SetPeerFilter_rejects(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n2.SetPeerFilter(func(peer.ID, *p2p.InfoResponse) bool { return false })
+
+	_, err := n1.RequestInfo(context.Background(), n2.ID())
+	assert.Error(t, err)
+}
+
+func TestPeerInfoCache_expiresAndIsBounded(t *testing.T) {
+	defer func(ttl time.Duration, count int) { peerInfoTTL, peerInfoMaxCount = ttl, count }(peerInfoTTL, peerInfoMaxCount)
+	peerInfoMaxCount = 2
+
+	c := &peerInfoCache{}
+	c.add("a", &p2p.InfoResponse{Nickname: "a"})
+	c.add("b", &p2p.InfoResponse{Nickname: "b"})
+	c.add("c", &p2p.InfoResponse{Nickname: "c"})
+
+	assert.Len(t, c.infos, 2)
+	assert.Nil(t, c.get("a"), "the entry that expires next is replaced")
+	assert.Equal(t, "c", c.get("c").Nickname)
+
+	peerInfoTTL = -time.Second
+	c.add("d", &p2p.InfoResponse{Nickname: "d"})
+	assert.Nil(t, c.get("d"))
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// The maximum number of streams a single peer may open to the node
+// at the same time.
+var maxInboundStreamsPerPeer = 32
+
+// The maximum number of push requests a single peer may have
+// pending at the same time.
+var maxInboundPushStreamsPerPeer = 2
+
+// The maximum number of files a single peer may pull from the node
+// at the same time.
+var maxInboundPullStreamsPerPeer = 4
+
+// resourceManager creates a resource manager with the default libp2p
+// limits that additionally restricts the streams of a single peer.
+func resourceManager() (network.ResourceManager, error) {
+	limits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&limits)
+
+	partial := rcmgr.PartialLimitConfig{
+		PeerDefault: rcmgr.ResourceLimits{
+			StreamsInbound: rcmgr.LimitVal(maxInboundStreamsPerPeer),
+		},
+		ProtocolPeer: map[protocol.ID]rcmgr.ResourceLimits{
+			ProtocolPushRequest: {StreamsInbound: rcmgr.LimitVal(maxInboundPushStreamsPerPeer)},
+			ProtocolPull:        {StreamsInbound: rcmgr.LimitVal(maxInboundPullStreamsPerPeer)},
+		},
+	}
+
+	return rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(partial.Build(limits.AutoScale())))
+}
+
+// rateLimiter counts the events of each peer within a sliding window.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	lk        sync.Mutex // protects events and lastPrune
+	events    map[peer.ID][]time.Time
+	lastPrune time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		events: map[peer.ID][]time.Time{},
+	}
+}
+
+// Allow records an event of the given peer and returns false
+// if the peer exceeded the limit within the window.
+func (r *rateLimiter) Allow(peerID peer.ID) bool {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	now := appTime.Now()
+	if now.Sub(r.lastPrune) >= r.window {
+		r.prune(now)
+	}
+
+	events := r.events[peerID][:0]
+	for _, t := range r.events[peerID] {
+		if now.Sub(t) < r.window {
+			events = append(events, t)
+		}
+	}
+	events = append(events, now)
+	r.events[peerID] = events
+
+	return len(events) <= r.limit
+}
+
+// prune forgets the peers without events within the window, so peers
+// that were only seen once don't stay in memory. The caller must hold
+// the lock.
+func (r *rateLimiter) prune(now time.Time) {
+	for peerID, events := range r.events {
+		if len(events) == 0 || now.Sub(events[len(events)-1]) >= r.window {
+			delete(r.events, peerID)
+		}
+	}
+	r.lastPrune = now
+}
+
+// Block disconnects the given peer and rejects its connections until
+// the given time. The zero time blocks the peer permanently.
+func (n *Node) Block(peerID peer.ID, until time.Time) {
+	if n.gater != nil {
+		n.gater.Block(peerID, until)
+	}
+	n.Network().ClosePeer(peerID)
+}
+
+// IsBlocked returns true if the given peer is currently blocked.
+func (n *Node) IsBlocked(peerID peer.ID) bool {
+	return n.gater != nil && n.gater.IsBlocked(peerID)
+}
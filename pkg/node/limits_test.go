@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+type acceptingPushHandler struct {
+	requests chan *p2p.PushRequest
+}
+
+func (h *acceptingPushHandler) HandlePushRequest(req *p2p.PushRequest) (bool, error) {
+	h.requests <- req
+	return true, nil
+}
+
+// acceptPushRequests lets the given node accept all push requests
+// and returns the handler that collects them.
+func acceptPushRequests(n *Node) *acceptingPushHandler {
+	handler := &acceptingPushHandler{requests: make(chan *p2p.PushRequest, 16)}
+	n.RegisterRequestHandler(handler)
+	return handler
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	r := newRateLimiter(2, 50*time.Millisecond)
+	peerID := peer.ID("peer")
+
+	assert.True(t, r.Allow(peerID))
+	assert.True(t, r.Allow(peerID))
+	assert.False(t, r.Allow(peerID))
+	assert.True(t, r.Allow(peer.ID("other")))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, r.Allow(peerID))
+}
+
+func TestRateLimiter_Allow_forgetsQuietPeers(t *testing.T) {
+	r := newRateLimiter(2, 50*time.Millisecond)
+
+	assert.True(t, r.Allow(peer.ID("peer")))
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, r.Allow(peer.ID("other")))
+
+	assert.Len(t, r.events, 1)
+	assert.Contains(t, r.events, peer.ID("other"))
+}
+
+func TestPushProtocol_blocksFloodingPeer(t *testing.T) {
+	defer func(limit int) { pushRateLimit = limit }(pushRateLimit)
+	pushRateLimit = 2
+
+	sender, receiver := nodePair(t, nil)
+	handler := acceptPushRequests(receiver)
+	ctx := context.Background()
+
+	for i := 0; i < pushRateLimit; i++ {
+		accepted, err := sender.SendPushRequest(ctx, receiver.ID(), p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+		require.NoError(t, err)
+		assert.True(t, accepted)
+		<-handler.requests
+	}
+
+	_, err := sender.SendPushRequest(ctx, receiver.ID(), p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+	assert.Error(t, err)
+	assert.True(t, receiver.IsBlocked(sender.ID()))
+	assert.Empty(t, handler.requests)
+
+	// The blocked peer can't connect anymore.
+	sender.Peerstore().AddAddrs(receiver.ID(), receiver.Addrs(), time.Minute)
+	_, err = sender.SendPushRequest(ctx, receiver.ID(), p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+	assert.Error(t, err)
+	assert.Empty(t, handler.requests)
+}
+
+func TestNode_Read_rejectsLargeMessages(t *testing.T) {
+	defer func(size int64) { maxMessageSize = size }(maxMessageSize)
+	maxMessageSize = 1024
+
+	sender, receiver := nodePair(t, nil)
+	handler := acceptPushRequests(receiver)
+
+	_, err := sender.SendPushRequest(context.Background(), receiver.ID(), p2p.NewPushRequest(strings.Repeat("a", 2048), 1, cid.Cid{}))
+	assert.Error(t, err)
+	assert.Empty(t, handler.requests)
+}
@@ -0,0 +1,137 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolList = "/p2p/list/0.0.1"
+
+// The maximum number of entries of a single list response.
+var listPageSize int64 = 200
+
+// ListProtocol lets peers browse the catalog of shared files.
+type ListProtocol struct {
+	node *Node
+	lk   sync.RWMutex
+	lh   ListHandler
+}
+
+// ListHandler decides which directories peers may list.
+type ListHandler interface {
+	// HandleListRequest returns the entries of the shared directory at
+	// the given path, sorted by name, if the given peer may list it.
+	HandleListRequest(peerID peer.ID, path string) ([]*p2p.ListEntry, error)
+}
+
+// NewListProtocol initializes a new ListProtocol object. Peers can't
+// list anything until a handler is registered.
+func NewListProtocol(node *Node) *ListProtocol {
+	return &ListProtocol{node: node, lk: sync.RWMutex{}}
+}
+
+func (l *ListProtocol) RegisterListHandler(lh ListHandler) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.lh = lh
+	l.node.SetStreamHandler(ProtocolList, l.onListRequest)
+}
+
+func (l *ListProtocol) UnregisterListHandler() {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.node.RemoveStreamHandler(ProtocolList)
+	l.lh = nil
+}
+
+func (l *ListProtocol) onListRequest(s network.Stream) {
+	defer s.Close()
+
+	l.lk.RLock()
+	lh := l.lh
+	l.lk.RUnlock()
+
+	if lh == nil {
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+
+	req := &p2p.ListRequest{}
+	if err := l.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	entries, err := lh.HandleListRequest(s.Conn().RemotePeer(), CleanSharePath(req.Path))
+	resp := p2p.NewListResponse(err)
+	if err == nil {
+		resp.Entries = page(entries, req.Offset, req.Limit)
+		resp.Total = int64(len(entries))
+	}
+
+	if err = l.node.Send(s, resp); err != nil {
+		log.Infoln(err)
+	}
+}
+
+// page returns at most limit entries starting at the given offset.
+// The limit is capped at listPageSize.
+func page(entries []*p2p.ListEntry, offset int64, limit int64) []*p2p.ListEntry {
+	if limit <= 0 || limit > listPageSize {
+		limit = listPageSize
+	}
+	if offset < 0 || offset >= int64(len(entries)) {
+		return nil
+	}
+	if end := offset + limit; end < int64(len(entries)) {
+		return entries[offset:end]
+	}
+	return entries[offset:]
+}
+
+// List requests the entries of the shared directory at the given path
+// of the given peer, starting at the given offset. The response holds
+// a single page of entries and the total number of entries.
+func (l *ListProtocol) List(ctx context.Context, peerID peer.ID, path string, offset int64) (*p2p.ListResponse, error) {
+
+	s, err := l.node.NewStream(withRelayedConns(ctx, "list"), peerID, ProtocolList)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err = l.node.Send(s, p2p.NewListRequest(path, offset, listPageSize)); err != nil {
+		return nil, err
+	}
+
+	resp := &p2p.ListResponse{}
+	if err = l.node.Read(s, resp); err != nil {
+		return nil, err
+	}
+
+	if author, err := resp.PeerID(); err != nil || author != peerID {
+		return nil, fmt.Errorf("received list response of unexpected peer %q", resp.GetHeader().GetNodeId())
+	} else if !resp.Ok {
+		return nil, fmt.Errorf("peer %s refused to list %q: %s", peerID, path, resp.Error)
+	}
+
+	return resp, nil
+}
+
+// CleanSharePath returns the shortest slash separated path that is
+// equivalent to the given one, relative to the root of a share. The
+// root itself is the empty path.
+func CleanSharePath(p string) string {
+	return path.Clean("/" + p)[1:]
+}
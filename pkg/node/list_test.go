@@ -0,0 +1,65 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// sharedDirs is a ListHandler that lets everyone list the given
+// directories.
+type sharedDirs map[string][]*p2p.ListEntry
+
+func (d sharedDirs) HandleListRequest(_ peer.ID, path string) ([]*p2p.ListEntry, error) {
+	entries, found := d[path]
+	if !found {
+		return nil, fmt.Errorf("%q isn't a shared directory", path)
+	}
+	return entries, nil
+}
+
+func TestListProtocol_List_paginates(t *testing.T) {
+	defer func(size int64) { listPageSize = size }(listPageSize)
+	listPageSize = 2
+
+	entries := []*p2p.ListEntry{{Name: "a"}, {Name: "b", Dir: true}, {Name: "c"}}
+	n1, n2 := nodePair(t, nil)
+	n2.RegisterListHandler(sharedDirs{"reports": entries})
+
+	resp, err := n1.List(context.Background(), n2.ID(), "/reports/", 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, resp.Total)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, "a", resp.Entries[0].Name)
+	assert.True(t, resp.Entries[1].Dir)
+
+	resp, err = n1.List(context.Background(), n2.ID(), "reports", 2)
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 1)
+	assert.Equal(t, "c", resp.Entries[0].Name)
+
+	resp, err = n1.List(context.Background(), n2.ID(), "reports", 3)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Entries)
+}
+
+func TestListProtocol_List_refused(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n2.RegisterListHandler(sharedDirs{})
+
+	_, err := n1.List(context.Background(), n2.ID(), "reports", 0)
+	assert.ErrorContains(t, err, "isn't a shared directory")
+}
+
+func TestCleanSharePath(t *testing.T) {
+	assert.Equal(t, "", CleanSharePath(""))
+	assert.Equal(t, "", CleanSharePath("/"))
+	assert.Equal(t, "a/b", CleanSharePath("a//b/"))
+	assert.Equal(t, "b", CleanSharePath("../../a/../b"))
+}
@@ -2,8 +2,6 @@ package node
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"sort"
 	"sync"
 	"time"
@@ -26,72 +24,229 @@ var (
 	appTime app.Timer = app.Time{}
 )
 
+// announcedPeer is a peer that announced itself under an mDNS service
+// name. It's forgotten when its timer fires because it wasn't seen
+// again in the meantime.
+type announcedPeer struct {
+	*PeerInfo
+	service string
+	timer   *time.Timer
+}
+
 // MDNSProtocol encapsulates the logic for discovering peers
 // in via multicast DNS in the local network.
 type MDNSProtocol struct {
+	peerEvents
 	node         *Node
+	lk           sync.Mutex // protects mdnsServ, browsers and expiry
 	mdnsServ     mdns.Service
+	browsers     []mdns.Service
+	expiry       *time.Timer
 	MdnsInterval time.Duration
 	Peers        *sync.Map
+
+	// The mDNS service name under which this node announces itself.
+	// The node stays invisible if it's empty.
+	MdnsAdvertise string
+
+	// The mDNS service names that are searched for peers.
+	MdnsBrowse []string
+
+	// The tag of the private swarm the node belongs to, if any. It's
+	// appended to the service names on the wire, so nodes of other
+	// swarms don't find this node and vice versa.
+	SwarmTag string
 }
 
 // NewMDNSProtocol creates a new MDNSProtocol struct with
 // sane defaults.
 func NewMDNSProtocol(node *Node) *MDNSProtocol {
 	m := &MDNSProtocol{
-		node:         node,
-		MdnsInterval: time.Second,
-		Peers:        &sync.Map{},
+		node:          node,
+		MdnsInterval:  time.Second,
+		Peers:         &sync.Map{},
+		MdnsAdvertise: commons.ServiceTag,
+		MdnsBrowse:    []string{commons.ServiceTag},
 	}
 	return m
 }
 
+// Start implements the Discoverer interface.
+func (m *MDNSProtocol) Start(ctx context.Context) error {
+	return m.StartMdnsService(ctx)
+}
+
+// Stop implements the Discoverer interface.
+func (m *MDNSProtocol) Stop() error {
+	return m.StopMdnsService()
+}
+
+// SetMdnsServices configures the service name this node announces
+// itself with and the service names it searches for peers. It must
+// be called before the mDNS service is started.
+func (m *MDNSProtocol) SetMdnsServices(advertise string, browse ...string) {
+	m.MdnsAdvertise = advertise
+	m.MdnsBrowse = browse
+}
+
 // StartMdnsService starts the mDNS service and registers the
 // MDNSProtocol to be notified for every newly discovered peer.
 func (m *MDNSProtocol) StartMdnsService(ctx context.Context) error {
-	if m.mdnsServ != nil {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.startMdnsService(ctx)
+}
+
+// StartMdnsServiceFor starts the mDNS service like StartMdnsService
+// and stops it automatically after the given duration. The expired
+// callback is called afterwards and may be nil.
+func (m *MDNSProtocol) StartMdnsServiceFor(ctx context.Context, d time.Duration, expired func()) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if err := m.startMdnsService(ctx); err != nil {
+		return err
+	}
+
+	if m.expiry != nil {
+		m.expiry.Stop()
+	}
+
+	m.expiry = appTime.AfterFunc(d, func() {
+		if err := m.StopMdnsService(); err != nil {
+			log.Infoln(err)
+		}
+		if expired != nil {
+			expired()
+		}
+	})
+
+	return nil
+}
+
+// IsMdnsServiceRunning returns true if the mDNS service is started.
+func (m *MDNSProtocol) IsMdnsServiceRunning() bool {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.mdnsServ != nil || len(m.browsers) > 0
+}
+
+func (m *MDNSProtocol) startMdnsService(ctx context.Context) error {
+	if m.mdnsServ != nil || len(m.browsers) > 0 {
 		return nil
 	}
 
-	mdns := mdns.NewMdnsService(m.node.Host, commons.ServiceTag, m)
-	m.mdnsServ = mdns
-	err := m.mdnsServ.Start()
-	if err != nil {
-		log.Infof("Starting the mDNS service failed: %s", err)
-		return err
+	if m.MdnsAdvertise != "" {
+		// The libp2p mDNS service always searches for the service it
+		// announces. Drop those peers if we're not interested in them.
+		var notifee mdns.Notifee = discardNotifee{}
+		if m.browses(m.MdnsAdvertise) {
+			notifee = &serviceNotifee{m: m, service: m.MdnsAdvertise}
+		}
+
+		m.mdnsServ = mdns.NewMdnsService(m.node.Host, m.serviceName(m.MdnsAdvertise), notifee)
+		err := m.mdnsServ.Start()
+		if err != nil {
+			log.Infof("Starting the mDNS service failed: %s", err)
+			return err
+		}
+	}
+
+	for _, service := range m.MdnsBrowse {
+		if service == m.MdnsAdvertise {
+			continue
+		}
+
+		b := newMdnsBrowser(m.node.ID(), m.serviceName(service), &serviceNotifee{m: m, service: service})
+		if err := b.Start(); err != nil {
+			log.Infof("Browsing for mDNS service %s failed: %s", service, err)
+			return err
+		}
+		m.browsers = append(m.browsers, b)
 	}
+
 	return nil
 }
 
 // StopMdnsService stops the mDNS service and clears the list
 // of peers.
 func (m *MDNSProtocol) StopMdnsService() error {
-	if m.mdnsServ == nil {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if m.expiry != nil {
+		m.expiry.Stop()
+		m.expiry = nil
+	}
+
+	if m.mdnsServ == nil && len(m.browsers) == 0 {
 		return nil
 	}
 
-	err := m.mdnsServ.Close()
-	if err != nil {
-		return err
+	if m.mdnsServ != nil {
+		err := m.mdnsServ.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, b := range m.browsers {
+		if err := b.Close(); err != nil {
+			return err
+		}
 	}
 
 	m.Peers.Range(func(key, value interface{}) bool {
-		value.(PeerInfo).timer.Stop()
+		p := value.(*announcedPeer)
+		p.timer.Stop()
+		m.emit(PeerEvent{Type: PeerLeft, Peer: p.pi})
 		return true
 	})
 
 	m.mdnsServ = nil
+	m.browsers = nil
 	// Clearning the list of peers
 	m.Peers = &sync.Map{}
 
 	return nil
 }
 
-type PeerInfo struct {
-	pi    peer.AddrInfo
-	timer *time.Timer
+// serviceName returns the name under which the given service is
+// announced and searched for in the swarm of the node.
+func (m *MDNSProtocol) serviceName(service string) string {
+	if m.SwarmTag == "" {
+		return service
+	}
+	return service + "-" + m.SwarmTag
+}
+
+// browses returns true if the given service is searched for peers.
+func (m *MDNSProtocol) browses(service string) bool {
+	for _, b := range m.MdnsBrowse {
+		if b == service {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceNotifee remembers the mDNS service a peer was found with.
+type serviceNotifee struct {
+	m       *MDNSProtocol
+	service string
+}
+
+func (n *serviceNotifee) HandlePeerFound(pi peer.AddrInfo) {
+	n.m.handlePeerFound(pi, n.service)
 }
 
+// discardNotifee ignores all discovered peers.
+type discardNotifee struct{}
+
+func (discardNotifee) HandlePeerFound(peer.AddrInfo) {}
+
 // HandlePeerFound stores every newly found peer in a map.
 // Every map entry gets a timer assigned that removes the
 // entry after a garbage collection timeout if the peer
@@ -99,16 +254,44 @@ type PeerInfo struct {
 // again we reset the time to start again from that point
 // in time.
 func (m *MDNSProtocol) HandlePeerFound(pi peer.AddrInfo) {
+	m.handlePeerFound(pi, "")
+}
+
+func (m *MDNSProtocol) handlePeerFound(pi peer.AddrInfo, service string) {
 	savedPeer, ok := m.Peers.Load(pi.ID)
 	if ok {
-		savedPeer.(PeerInfo).timer.Reset(gcDuration)
+		savedPeer.(*announcedPeer).timer.Reset(gcDuration)
 	} else {
 		// If the peer is not in the list, add it with a timer
 		t := appTime.AfterFunc(gcDuration, func() {
 			m.Peers.Delete(pi.ID)
+			m.emit(PeerEvent{Type: PeerLeft, Peer: pi})
 		})
-		m.Peers.Store(pi.ID, PeerInfo{pi, t})
+		p := &announcedPeer{PeerInfo: NewPeerInfo(pi, SourceMDNS), service: service, timer: t}
+		m.Peers.Store(pi.ID, p)
+		m.emit(PeerEvent{Type: PeerJoined, Peer: pi})
+		if m.node.InfoProtocol != nil {
+			go m.updatePeerInfo(p.PeerInfo)
+		}
+	}
+}
+
+// PeerInfo returns the stored information about the given peer.
+func (m *MDNSProtocol) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	p, ok := m.Peers.Load(peerID)
+	if !ok {
+		return nil, false
+	}
+	return p.(*announcedPeer).PeerInfo, true
+}
+
+// Service returns the mDNS service name the given peer was found with.
+func (m *MDNSProtocol) Service(peerID peer.ID) (string, bool) {
+	p, ok := m.Peers.Load(peerID)
+	if !ok {
+		return "", false
 	}
+	return p.(*announcedPeer).service, true
 }
 
 // PeersList returns a sorted list of address information
@@ -116,7 +299,7 @@ func (m *MDNSProtocol) HandlePeerFound(pi peer.AddrInfo) {
 func (m *MDNSProtocol) PeersList() []peer.AddrInfo {
 	peers := []peer.AddrInfo{}
 	m.Peers.Range(func(key, value interface{}) bool {
-		peers = append(peers, value.(PeerInfo).pi)
+		peers = append(peers, value.(*announcedPeer).pi)
 		return true
 	})
 
@@ -127,11 +310,10 @@ func (m *MDNSProtocol) PeersList() []peer.AddrInfo {
 	return peers
 }
 
-// PrintPeers dumps the given list of peers to the screen
-// to be selected by the user via its index.
-func (m *MDNSProtocol) PrintPeers(peers []peer.AddrInfo) {
-	for i, p := range peers {
-		fmt.Fprintf(os.Stdout, "[%d] %s\n", i, p.ID)
+// updatePeerInfo queries the information of the given peer and announces
+// the update to the subscribers.
+func (m *MDNSProtocol) updatePeerInfo(p *PeerInfo) {
+	if m.node.fillPeerInfo(p) {
+		m.emit(PeerEvent{Type: PeerUpdated, Peer: p.pi})
 	}
-	fmt.Fprintln(os.Stdout)
 }
@@ -0,0 +1,94 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	"github.com/libp2p/zeroconf/v2"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	mdnsDomain    = "local"
+	dnsaddrPrefix = "dnsaddr="
+)
+
+// mdnsBrowser looks for peers that advertise the given mDNS
+// service without announcing the local node itself. This allows
+// senders to look for receivers without showing up in the peer
+// list of other senders.
+type mdnsBrowser struct {
+	self        peer.ID
+	serviceName string
+	notifee     mdns.Notifee
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func newMdnsBrowser(self peer.ID, serviceName string, notifee mdns.Notifee) *mdnsBrowser {
+	b := &mdnsBrowser{
+		self:        self,
+		serviceName: serviceName,
+		notifee:     notifee,
+	}
+	b.ctx, b.ctxCancel = context.WithCancel(context.Background())
+	return b
+}
+
+// Start browses for the service in the background.
+func (b *mdnsBrowser) Start() error {
+	b.wg.Add(2)
+	entries := make(chan *zeroconf.ServiceEntry, 1000)
+	go func() {
+		defer b.wg.Done()
+		for entry := range entries {
+			b.handleEntry(entry)
+		}
+	}()
+	go func() {
+		defer b.wg.Done()
+		// Browse closes the entries channel when it returns.
+		_ = zeroconf.Browse(b.ctx, b.serviceName, mdnsDomain, entries)
+	}()
+	return nil
+}
+
+// Close stops browsing and waits for the background routines to return.
+func (b *mdnsBrowser) Close() error {
+	b.ctxCancel()
+	b.wg.Wait()
+	return nil
+}
+
+// handleEntry extracts the peer addresses from the TXT records the
+// same way the libp2p mDNS service does.
+func (b *mdnsBrowser) handleEntry(entry *zeroconf.ServiceEntry) {
+	addrs := make([]ma.Multiaddr, 0, len(entry.Text))
+	for _, txt := range entry.Text {
+		if !strings.HasPrefix(txt, dnsaddrPrefix) {
+			continue
+		}
+		addr, err := ma.NewMultiaddr(txt[len(dnsaddrPrefix):])
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+
+	infos, err := peer.AddrInfosFromP2pAddrs(addrs...)
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		if info.ID == b.self {
+			continue
+		}
+		go b.notifee.HandlePeerFound(info)
+	}
+}
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ansuman12chat/p2p/internal/app"
 	"github.com/ansuman12chat/p2p/internal/mock"
+	"github.com/ansuman12chat/p2p/pkg/commons"
 )
 
 func setup(t *testing.T) *gomock.Controller {
@@ -130,3 +132,79 @@ func TestMDNSProtocol_PeerList_returnsListOfPeers(t *testing.T) {
 	assert.Equal(t, p2, list[1])
 	assert.Equal(t, p3, list[2])
 }
+
+func TestMDNSProtocol_serviceNotifee_storesService(t *testing.T) {
+	n := mockNode(t)
+	p := NewMDNSProtocol(n)
+
+	notifee := &serviceNotifee{m: p, service: commons.MdnsServiceReceive}
+	notifee.HandlePeerFound(peer.AddrInfo{ID: peer.ID("peer-id")})
+
+	service, found := p.Service(peer.ID("peer-id"))
+	assert.True(t, found)
+	assert.Equal(t, commons.MdnsServiceReceive, service)
+}
+
+func TestMDNSProtocol_SetMdnsServices(t *testing.T) {
+	n := mockNode(t)
+	p := NewMDNSProtocol(n)
+	assert.Equal(t, commons.ServiceTag, p.MdnsAdvertise)
+	assert.True(t, p.browses(commons.ServiceTag))
+
+	p.SetMdnsServices(commons.MdnsServiceSend, commons.MdnsServiceReceive)
+	assert.Equal(t, commons.MdnsServiceSend, p.MdnsAdvertise)
+	assert.True(t, p.browses(commons.MdnsServiceReceive))
+	assert.False(t, p.browses(commons.MdnsServiceSend))
+}
+
+func TestMDNSProtocol_StartMdnsServiceFor_callsExpiredAfterDuration(t *testing.T) {
+	n := mockNode(t)
+	p := NewMDNSProtocol(n)
+	p.SetMdnsServices("")
+
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	mTime := mock.NewMockTimer(ctrl)
+	mTime.EXPECT().
+		AfterFunc(gomock.Eq(10*time.Minute), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(d time.Duration, f func()) *time.Timer {
+			return time.AfterFunc(0, f)
+		})
+	appTime = mTime
+
+	expired := make(chan struct{})
+	err := p.StartMdnsServiceFor(context.Background(), 10*time.Minute, func() {
+		close(expired)
+	})
+	assert.NoError(t, err)
+
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("expired callback was not called")
+	}
+	assert.False(t, p.IsMdnsServiceRunning())
+}
+
+func TestMDNSProtocol_HandlePeerFound_emitsEvents(t *testing.T) {
+	n := mockNode(t)
+	p := NewMDNSProtocol(n)
+
+	ctrl := setup(t)
+	defer teardown(t, ctrl)
+
+	gcDurationTmp := gcDuration
+	gcDuration = 10 * time.Millisecond
+	defer func() { gcDuration = gcDurationTmp }()
+
+	events, unsubscribe := p.Subscribe()
+	defer unsubscribe()
+
+	pi := peer.AddrInfo{ID: peer.ID("peer-id")}
+	p.HandlePeerFound(pi)
+
+	assert.Equal(t, PeerEvent{Type: PeerJoined, Peer: pi}, <-events)
+	assert.Equal(t, PeerEvent{Type: PeerLeft, Peer: pi}, <-events)
+}
@@ -1,17 +1,22 @@
 package node
 
 import (
+	"bufio"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/proto"
 
@@ -23,24 +28,72 @@ import (
 // message authentication due to bogus keys.
 var authenticateMessages = true
 
+// The maximum size of a message that Read accepts.
+var maxMessageSize int64 = 1 << 20
+
 // Node encapsulates the logic for sending and receiving messages.
 type Node struct {
 	host.Host
 	*MDNSProtocol
+	*InfoProtocol
+	Discovery *Discovery
 	*PushProtocol
 	*TransferProtocol
+	*RelayProtocol
+	*PairingProtocol
+	*PullProtocol
+	*ListProtocol
+	*SessionProtocol
+
+	gater *ConnectionGater
+
+	// The format of short authentication strings.
+	sasFormat string
+
+	// The certificate of this node, if any, and the authorities
+	// whose certificates other peers are trusted with.
+	certificate *p2p.Certificate
+	authorities *Authorities
+
+	// The statement that this node belongs to the same user as
+	// another one, if any, and whether other nodes were linked to
+	// this one.
+	deviceLink  *p2p.DeviceLink
+	ownsDevices bool
+
+	// The information that peers presented.
+	infos peerInfoCache
+
+	// The keys this node used before and the configuration that
+	// the rotations of other peers are applied to.
+	rotations  []*p2p.IdentityRotation
+	rotationLk sync.Mutex // protects contacts and blocklist
+	contacts   *config.AddressBook
+	blocklist  *config.Blocklist
 }
 
 // Init creates a new, fully initialized node with the given options.
+// It uses the configuration attached to the context by config.FillContext
+// and falls back to the default profile otherwise.
 func Init(ctx context.Context, opts ...libp2p.Option) (*Node, error) {
 
-	conf, err := config.LoadConfig()
-	if err != nil {
-		return nil, err
+	conf, ok := config.FromContext(ctx)
+	if !ok {
+		var err error
+		conf, err = config.LoadConfig("")
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if !conf.Identity.IsInitialized() {
-		err = conf.Identity.GenerateKeyPair()
+		err := conf.Identity.GenerateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+
+		// Persist the key pair, so the peer ID stays stable across runs.
+		err = conf.Identity.Save()
 		if err != nil {
 			return nil, err
 		}
@@ -50,16 +103,108 @@ func Init(ctx context.Context, opts ...libp2p.Option) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	opts = append(opts, libp2p.Identity(key))
+
+	if err = ValidateTransport(conf.Settings.Transport); err != nil {
+		return nil, err
+	} else if conf.Settings.Transport != "" {
+		opts = append(opts, libp2p.DialRanker(transportDialRanker(conf.Settings.Transport)))
+	}
+
+	if err = ValidateSASFormat(conf.Settings.VerificationFormat); err != nil {
+		return nil, err
+	}
+
+	var cert *p2p.Certificate
+	if conf.Settings.Certificate != "" {
+		if cert, err = loadOwnCertificate(conf.Settings.Certificate, key); err != nil {
+			return nil, err
+		}
+	}
+
+	var link *p2p.DeviceLink
+	if conf.Settings.DeviceLink != "" {
+		if link, err = loadOwnDeviceLink(conf.Settings.DeviceLink, key); err != nil {
+			return nil, err
+		}
+	}
+
+	var rotations []*p2p.IdentityRotation
+	for _, s := range conf.Identity.Rotations {
+		r, err := DecodeRotation(s)
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, r)
+	}
+
+	authorities, err := NewAuthorities(conf.Settings.TrustedCAs, conf.Settings.RevocationLists)
+	if err != nil {
+		return nil, err
+	}
+
+	gater, err := NewConnectionGater(conf.Settings.AllowPublic, conf.Settings.AllowCIDRs, conf.Settings.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range conf.Blocklist.PeerIDs {
+		peerID, err := peer.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer ID %q in blocklist: %w", id, err)
+		}
+		gater.Block(peerID, time.Time{})
+	}
+
+	var swarmTag string
+	if conf.Settings.SwarmKey != "" {
+		psk, err := LoadSwarmKey(conf.Settings.SwarmKey)
+		if err != nil {
+			return nil, err
+		}
+		swarmTag = SwarmTag(psk)
+		// Private networks aren't supported by QUIC and the other
+		// transports that bring their own encryption.
+		opts = append(opts, libp2p.PrivateNetwork(psk), libp2p.Transport(tcp.NewTCPTransport))
+	}
+
+	rm, err := resourceManager()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, libp2p.ConnectionGater(gater), libp2p.ResourceManager(rm))
+
+	// The relay protocol needs to exist before the host, because
+	// it adds the relayed addresses to the addresses of the host.
+	relay := &RelayProtocol{}
+
+	opts = append(opts, libp2p.Identity(key), libp2p.AddrsFactory(relay.addrsFactory))
 	h, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	node := &Node{Host: h}
+	node := &Node{Host: h, RelayProtocol: relay, gater: gater, sasFormat: conf.Settings.VerificationFormat,
+		certificate: cert, authorities: authorities, deviceLink: link, ownsDevices: conf.Settings.OwnsDevices,
+		rotations: rotations, contacts: conf.AddressBook, blocklist: conf.Blocklist,
+	}
+	relay.node = node
 	node.MDNSProtocol = NewMDNSProtocol(node)
+	node.MDNSProtocol.SwarmTag = swarmTag
+	node.InfoProtocol = NewInfoProtocol(node, conf.Settings.Nickname)
+	node.Discovery = NewDiscovery(node.MDNSProtocol)
+	if len(conf.Settings.StaticPeers) > 0 {
+		static, err := NewStaticDiscoverer(node, conf.Settings.StaticPeers)
+		if err != nil {
+			h.Close()
+			return nil, err
+		}
+		node.Discovery.Add(static)
+	}
 	node.PushProtocol = NewPushProtocol(node)
 	node.TransferProtocol = NewTransferProtocol(node)
+	node.PairingProtocol = NewPairingProtocol(node)
+	node.PullProtocol = NewPullProtocol(node)
+	node.ListProtocol = NewListProtocol(node)
+	node.SessionProtocol = NewSessionProtocol(node)
 
 	return node, nil
 }
@@ -69,11 +214,29 @@ func Init(ctx context.Context, opts ...libp2p.Option) (*Node, error) {
 func (n *Node) Send(s network.Stream, msg p2p.HeaderMessage) error {
 	defer s.CloseWrite()
 
+	data, err := n.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// Transmit the data.
+	_, err = s.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Marshal attaches a header that is signed with the node's private key
+// to the message msg and transforms it to binary.
+func (n *Node) Marshal(msg p2p.HeaderMessage) ([]byte, error) {
+
 	// Get own public key.
 	pubKey := n.Host.Peerstore().PubKey(n.Host.ID())
 	pubKeyBytes, err := crypto.MarshalPublicKey(pubKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	hdr := &p2p.Header{
@@ -87,31 +250,20 @@ func (n *Node) Send(s network.Stream, msg p2p.HeaderMessage) error {
 	// Transform msg to binary to calculate the signature.
 	data, err := proto.Marshal(msg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Sign the data and attach the signature.
 	key := n.Host.Peerstore().PrivKey(n.Host.ID())
 	signature, err := key.Sign(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	hdr.Signature = signature
 	msg.SetHeader(hdr) // Maybe unnecessary
 
 	// Transform msg + signature to binary.
-	data, err = proto.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	// Transmit the data.
-	_, err = s.Write(data)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return proto.Marshal(msg)
 }
 
 // authenticateMessage verifies the authenticity of the message payload.
@@ -167,9 +319,13 @@ func (n *Node) authenticateMessage(msg p2p.HeaderMessage) (bool, error) {
 // Read drains the given stream and parses the content. It unmarshalls
 // it into the protobuf object. It also verifies the authenticity of the message.
 // Read closes the stream for reading but leaves it open for writing.
+// Messages larger than maxMessageSize are rejected and the stream is reset.
 func (n *Node) Read(s network.Stream, data p2p.HeaderMessage) error {
 	defer s.CloseRead()
-	buf, err := io.ReadAll(s)
+	buf, err := io.ReadAll(io.LimitReader(s, maxMessageSize+1))
+	if err == nil && int64(len(buf)) > maxMessageSize {
+		err = fmt.Errorf("message of peer %s exceeds %d bytes", s.Conn().RemotePeer(), maxMessageSize)
+	}
 	if err != nil {
 		if err2 := s.Reset(); err2 != nil {
 			err = errors.Wrap(err, err2.Error())
@@ -177,7 +333,13 @@ func (n *Node) Read(s network.Stream, data p2p.HeaderMessage) error {
 		return err
 	}
 
-	if err = proto.Unmarshal(buf, data); err != nil {
+	return n.Unmarshal(buf, data)
+}
+
+// Unmarshal parses the given binary data into the protobuf object
+// and verifies the authenticity of the message.
+func (n *Node) Unmarshal(buf []byte, data p2p.HeaderMessage) error {
+	if err := proto.Unmarshal(buf, data); err != nil {
 		return err
 	}
 
@@ -193,6 +355,37 @@ func (n *Node) Read(s network.Stream, data p2p.HeaderMessage) error {
 	return nil
 }
 
+// WriteMessage writes the message msg with a length prefix to the
+// stream s, so other data can follow it. It leaves the stream open.
+func (n *Node) WriteMessage(s network.Stream, msg p2p.HeaderMessage) error {
+	data, err := n.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Write(append(binary.AppendUvarint(nil, uint64(len(data))), data...))
+	return err
+}
+
+// ReadMessage parses a message that WriteMessage wrote from the given
+// reader and verifies its authenticity. The reader is left at the
+// data that follows the message.
+func (n *Node) ReadMessage(r *bufio.Reader, data p2p.HeaderMessage) error {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	} else if size > uint64(maxMessageSize) {
+		return fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+	}
+
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return err
+	}
+
+	return n.Unmarshal(buf, data)
+}
+
 // WaitForEOF waits for the EOF signal on the given stream.
 func (n *Node) WaitForEOF(s network.Stream) error {
 	// 10 sec timeout
@@ -217,3 +410,30 @@ func (n *Node) WaitForEOF(s network.Stream) error {
 	}
 	// Select on the done channel and a timeout channel.
 }
+
+// WaitForDisconnect waits until the node isn't connected to the given
+// peer anymore or the timeout expires.
+func (n *Node) WaitForDisconnect(peerID peer.ID, timeout time.Duration) {
+	sub, err := n.EventBus().Subscribe(new(event.EvtPeerConnectednessChanged))
+	if err != nil {
+		return
+	}
+	defer sub.Close()
+
+	if n.Network().Connectedness(peerID) != network.Connected {
+		return
+	}
+
+	expired := time.After(timeout)
+	for {
+		select {
+		case <-expired:
+			return
+		case e := <-sub.Out():
+			evt := e.(event.EvtPeerConnectednessChanged)
+			if evt.Peer == peerID && evt.Connectedness != network.Connected {
+				return
+			}
+		}
+	}
+}
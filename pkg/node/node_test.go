@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localNode creates a node that listens on the loopback interface and
+// runs the same protocols as the nodes of Init.
+func localNode(t *testing.T, nickname string) *Node {
+	relay := &RelayProtocol{}
+	gater, err := NewConnectionGater(false, nil, nil)
+	require.NoError(t, err)
+	h, err := libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.AddrsFactory(relay.addrsFactory),
+		libp2p.ConnectionGater(gater),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	n := &Node{Host: h, RelayProtocol: relay, gater: gater}
+	relay.node = n
+	n.MDNSProtocol = NewMDNSProtocol(n)
+	n.InfoProtocol = NewInfoProtocol(n, nickname)
+	n.Discovery = NewDiscovery()
+	n.PushProtocol = NewPushProtocol(n)
+	n.TransferProtocol = NewTransferProtocol(n)
+	n.PairingProtocol = NewPairingProtocol(n)
+	n.PullProtocol = NewPullProtocol(n)
+	n.ListProtocol = NewListProtocol(n)
+	n.SessionProtocol = NewSessionProtocol(n)
+	return n
+}
+
+// nodePair returns a sender and a receiver node. The sender is connected
+// to the receiver through the given relay node if it isn't nil, and
+// directly otherwise.
+func nodePair(t *testing.T, relay *Node) (*Node, *Node) {
+	sender := localNode(t, "sender")
+	receiver := localNode(t, "receiver")
+	ctx := context.Background()
+
+	if relay == nil {
+		require.NoError(t, sender.Connect(ctx, peer.AddrInfo{ID: receiver.ID(), Addrs: receiver.Addrs()}))
+		return sender, receiver
+	}
+
+	relayAddrs, err := relay.DialableAddrs()
+	require.NoError(t, err)
+
+	require.NoError(t, receiver.UseRelay(relayAddrs[0].String()))
+	require.NoError(t, receiver.ReserveRelay(ctx))
+	t.Cleanup(receiver.StopRelay)
+
+	require.Len(t, receiver.RelayAddrs(), 1)
+	assert.Contains(t, receiver.Addrs(), receiver.RelayAddrs()[0])
+
+	require.NoError(t, sender.UseRelay(relayAddrs[0].String()))
+
+	// Only tell the sender how to reach the receiver via the relay.
+	pi := sender.ViaRelay(peer.AddrInfo{ID: receiver.ID()})
+	require.Len(t, pi.Addrs, 1)
+	require.NoError(t, sender.Connect(ctx, pi))
+	require.True(t, sender.IsRelayed(receiver.ID()))
+	require.False(t, sender.IsRelayed(relay.ID()))
+
+	return sender, receiver
+}
@@ -0,0 +1,289 @@
+package node
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolPairing = "/p2p/pairing/0.2.0"
+
+// The number of pairing attempts after which a code is discarded,
+// so it can't be guessed by trying.
+var pairingMaxAttempts = 3
+
+// The largest nameplate of generated pairing codes.
+const pairingMaxNameplate = 99
+
+// ErrUnknownPairingCode is returned by Pair if the peer doesn't wait
+// for a pairing with the nameplate of the code.
+var ErrUnknownPairingCode = fmt.Errorf("unknown pairing code")
+
+// ErrPairingRejected is returned by Pair if the peer waits for a
+// pairing with the nameplate of the code, but the pairing failed,
+// e.g. because the rest of the code didn't match.
+var ErrPairingRejected = fmt.Errorf("pairing rejected")
+
+var errPairingAttempts = fmt.Errorf("too many failed pairing attempts, please start over with a new code")
+
+var pairingCodeRegex = regexp.MustCompile(`^([0-9]+)-[a-z]+-[a-z]+$`)
+
+// PairingProtocol binds two nodes to each other with a short code
+// like 7-purple-sausage. One node displays the code and the user
+// enters it on the other node. Both run a SPAKE2 exchange with the
+// code as password over their libp2p connection, so they learn each
+// other's peer ID without comparing it.
+type PairingProtocol struct {
+	node *Node
+
+	lk        sync.Mutex // protects all fields below
+	code      string
+	nameplate string
+	attempts  int
+	sessions  map[peer.ID][]byte
+	result    chan pairingResult
+}
+
+type pairingResult struct {
+	peerID peer.ID
+	err    error
+}
+
+// NewPairingProtocol creates a new PairingProtocol.
+func NewPairingProtocol(node *Node) *PairingProtocol {
+	return &PairingProtocol{node: node}
+}
+
+// GeneratePairingCode returns a random code made of a nameplate
+// and two words.
+func GeneratePairingCode() (string, error) {
+	nameplate, err := rand.Int(rand.Reader, big.NewInt(pairingMaxNameplate))
+	if err != nil {
+		return "", err
+	}
+
+	words := make([]string, 2)
+	for i := range words {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(pairingWords))))
+		if err != nil {
+			return "", err
+		}
+		words[i] = pairingWords[n.Int64()]
+	}
+
+	return fmt.Sprintf("%d-%s", nameplate.Int64()+1, strings.Join(words, "-")), nil
+}
+
+// ParsePairingCode normalizes the given code and returns it
+// together with its nameplate.
+func ParsePairingCode(code string) (string, string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	match := pairingCodeRegex.FindStringSubmatch(code)
+	if match == nil {
+		return "", "", fmt.Errorf("invalid pairing code %q, expected something like 7-purple-sausage", code)
+	}
+	return code, match[1], nil
+}
+
+// AwaitPairing waits until a peer pairs with the given code and
+// returns its peer ID. It fails if too many peers tried to pair
+// with a wrong code.
+func (p *PairingProtocol) AwaitPairing(ctx context.Context, code string) (peer.ID, error) {
+	code, nameplate, err := ParsePairingCode(code)
+	if err != nil {
+		return "", err
+	}
+
+	result := make(chan pairingResult, 1)
+
+	p.lk.Lock()
+	p.code = code
+	p.nameplate = nameplate
+	p.attempts = 0
+	p.sessions = map[peer.ID][]byte{}
+	p.result = result
+	p.lk.Unlock()
+
+	p.node.SetStreamHandler(ProtocolPairing, p.onPairingRequest)
+	defer func() {
+		p.node.RemoveStreamHandler(ProtocolPairing)
+		p.lk.Lock()
+		p.code = ""
+		p.sessions = nil
+		p.lk.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-result:
+		return r.peerID, r.err
+	}
+}
+
+// onPairingRequest handles both steps of a pairing attempt of the
+// remote peer.
+func (p *PairingProtocol) onPairingRequest(s network.Stream) {
+	defer s.Close()
+
+	req := &p2p.PairingRequest{}
+	if err := p.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	remote := s.Conn().RemotePeer()
+	resp := p.handleRequest(remote, req)
+	if err := p.node.Send(s, resp); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	if err := p.node.WaitForEOF(s); err != nil {
+		log.Infoln(err)
+	}
+}
+
+// handleRequest answers the given request of the given peer and
+// reports the outcome of the pairing once it's decided.
+func (p *PairingProtocol) handleRequest(remote peer.ID, req *p2p.PairingRequest) *p2p.PairingResponse {
+	if author, err := req.PeerID(); err != nil || author != remote {
+		return p2p.NewPairingResponse(fmt.Errorf("the request wasn't authored by the connected peer"))
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if p.code == "" || req.Nameplate != p.nameplate {
+		return p2p.NewPairingResponse(ErrUnknownPairingCode)
+	}
+
+	// First step: exchange the SPAKE2 elements.
+	if len(req.Element) > 0 {
+		if p.attempts >= pairingMaxAttempts {
+			if len(p.sessions) == 0 {
+				p.finish(pairingResult{err: errPairingAttempts})
+			}
+			return p2p.NewPairingResponse(fmt.Errorf("too many failed attempts"))
+		}
+		p.attempts++
+
+		spake, err := newSpake2([]byte(p.code), false)
+		if err != nil {
+			return p2p.NewPairingResponse(err)
+		}
+
+		keys, err := spake.finish(req.Element, []byte(remote), []byte(p.node.ID()))
+		if err != nil {
+			return p2p.NewPairingResponse(err)
+		}
+		p.sessions[remote] = keys.initiator
+
+		resp := p2p.NewPairingResponse(nil)
+		resp.Element = spake.element
+		resp.Confirmation = keys.responder
+		return resp
+	}
+
+	// Second step: verify that the peer derived the same keys.
+	expected, found := p.sessions[remote]
+	delete(p.sessions, remote)
+	if !found {
+		return p2p.NewPairingResponse(fmt.Errorf("no pairing attempt in progress"))
+	}
+
+	if !hmac.Equal(expected, req.Confirmation) {
+		log.Infof("Peer %s entered a wrong pairing code\n", remote)
+		if p.attempts >= pairingMaxAttempts && len(p.sessions) == 0 {
+			p.finish(pairingResult{err: errPairingAttempts})
+		}
+		return p2p.NewPairingResponse(fmt.Errorf("wrong pairing code"))
+	}
+
+	p.finish(pairingResult{peerID: remote})
+	return p2p.NewPairingResponse(nil)
+}
+
+// finish reports the given result and discards the code, so
+// it can't be used again.
+func (p *PairingProtocol) finish(r pairingResult) {
+	p.code = ""
+	p.sessions = map[peer.ID][]byte{}
+	p.result <- r
+}
+
+// Pair runs the pairing with the given code against the given peer,
+// which waits for it in AwaitPairing. It returns nil if both used the
+// same code.
+func (p *PairingProtocol) Pair(ctx context.Context, peerID peer.ID, code string) error {
+	code, nameplate, err := ParsePairingCode(code)
+	if err != nil {
+		return err
+	}
+
+	spake, err := newSpake2([]byte(code), true)
+	if err != nil {
+		return err
+	}
+
+	req := &p2p.PairingRequest{Nameplate: nameplate, Element: spake.element}
+	resp, err := p.sendPairingRequest(ctx, peerID, req)
+	if err != nil {
+		return err
+	}
+
+	keys, err := spake.finish(resp.Element, []byte(p.node.ID()), []byte(peerID))
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(keys.responder, resp.Confirmation) {
+		// Let the peer count the attempt as failed.
+		req = &p2p.PairingRequest{Nameplate: nameplate, Confirmation: []byte{0}}
+		_, _ = p.sendPairingRequest(ctx, peerID, req)
+		return fmt.Errorf("%w: wrong pairing code", ErrPairingRejected)
+	}
+
+	req = &p2p.PairingRequest{Nameplate: nameplate, Confirmation: keys.initiator}
+	_, err = p.sendPairingRequest(ctx, peerID, req)
+	return err
+}
+
+// sendPairingRequest sends the given request to the given peer and
+// returns the response if it was successful.
+func (p *PairingProtocol) sendPairingRequest(ctx context.Context, peerID peer.ID, req *p2p.PairingRequest) (*p2p.PairingResponse, error) {
+	s, err := p.node.NewStream(withRelayedConns(ctx, "pairing"), peerID, ProtocolPairing)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err = p.node.Send(s, req); err != nil {
+		return nil, err
+	}
+
+	resp := &p2p.PairingResponse{}
+	if err = p.node.Read(s, resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.Ok && resp.Error == ErrUnknownPairingCode.Error() {
+		return nil, ErrUnknownPairingCode
+	} else if !resp.Ok {
+		return nil, fmt.Errorf("%w: %s", ErrPairingRejected, resp.Error)
+	}
+
+	return resp, nil
+}
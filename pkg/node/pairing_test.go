@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// awaitPairing runs AwaitPairing of the given node in the background.
+func awaitPairing(n *Node, code string) <-chan pairingResult {
+	result := make(chan pairingResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		peerID, err := n.AwaitPairing(ctx, code)
+		result <- pairingResult{peerID: peerID, err: err}
+	}()
+
+	// Pairing requests fail until the node handles the protocol.
+	for !slices.Contains(n.Mux().Protocols(), ProtocolPairing) {
+		runtime.Gosched()
+	}
+	return result
+}
+
+func TestSpake2_agreesOnSamePassword(t *testing.T) {
+	a, err := newSpake2([]byte("7-purple-sausage"), true)
+	require.NoError(t, err)
+	b, err := newSpake2([]byte("7-purple-sausage"), false)
+	require.NoError(t, err)
+
+	ka, err := a.finish(b.element, []byte("a"), []byte("b"))
+	require.NoError(t, err)
+	kb, err := b.finish(a.element, []byte("a"), []byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, ka, kb)
+
+	// Different identities lead to different keys.
+	kc, err := b.finish(a.element, []byte("a"), []byte("c"))
+	require.NoError(t, err)
+	assert.NotEqual(t, ka, kc)
+}
+
+func TestSpake2_disagreesOnDifferentPasswords(t *testing.T) {
+	a, err := newSpake2([]byte("7-purple-sausage"), true)
+	require.NoError(t, err)
+	b, err := newSpake2([]byte("7-purple-sandwich"), false)
+	require.NoError(t, err)
+
+	ka, err := a.finish(b.element, []byte("a"), []byte("b"))
+	require.NoError(t, err)
+	kb, err := b.finish(a.element, []byte("a"), []byte("b"))
+	require.NoError(t, err)
+	assert.NotEqual(t, ka.initiator, kb.initiator)
+	assert.NotEqual(t, ka.responder, kb.responder)
+
+	_, err = a.finish([]byte{2, 1, 2, 3}, []byte("a"), []byte("b"))
+	assert.Error(t, err)
+
+	// An element that cancels out the blinding would yield a known key.
+	blind := new(edwards25519.Point).ScalarMult(a.w, spakeN)
+	_, err = a.finish(blind.Bytes(), []byte("a"), []byte("b"))
+	assert.Error(t, err)
+}
+
+func TestParsePairingCode(t *testing.T) {
+	code, nameplate, err := ParsePairingCode(" 7-Purple-Sausage\n")
+	require.NoError(t, err)
+	assert.Equal(t, "7-purple-sausage", code)
+	assert.Equal(t, "7", nameplate)
+
+	for _, invalid := range []string{"", "purple-sausage", "7-purple", "7-purple-sausage-extra", "x-purple-sausage"} {
+		_, _, err = ParsePairingCode(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	generated, err := GeneratePairingCode()
+	require.NoError(t, err)
+	_, _, err = ParsePairingCode(generated)
+	assert.NoError(t, err, generated)
+}
+
+func TestPairingProtocol_pairsWithSameCode(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+	result := awaitPairing(sender, "7-purple-sausage")
+
+	require.NoError(t, receiver.Pair(context.Background(), sender.ID(), "7-purple-sausage"))
+
+	r := <-result
+	require.NoError(t, r.err)
+	assert.Equal(t, receiver.ID(), r.peerID)
+}
+
+func TestPairingProtocol_rejectsWrongCode(t *testing.T) {
+	defer func(attempts int) { pairingMaxAttempts = attempts }(pairingMaxAttempts)
+	pairingMaxAttempts = 2
+
+	sender, receiver := nodePair(t, nil)
+	result := awaitPairing(sender, "7-purple-sausage")
+
+	// Codes with another nameplate don't count as attempts.
+	assert.ErrorIs(t, receiver.Pair(context.Background(), sender.ID(), "8-purple-sausage"), ErrUnknownPairingCode)
+
+	assert.Error(t, receiver.Pair(context.Background(), sender.ID(), "7-purple-sandwich"))
+	assert.Error(t, receiver.Pair(context.Background(), sender.ID(), "7-purple-salad"))
+
+	r := <-result
+	assert.ErrorIs(t, r.err, errPairingAttempts)
+
+	// The code is burnt, even the right one doesn't work anymore.
+	assert.Error(t, receiver.Pair(context.Background(), sender.ID(), "7-purple-sausage"))
+}
@@ -0,0 +1,34 @@
+package node
+
+// pairingWords are the words pairing codes are made of. They are
+// short, common and easy to tell apart when read aloud. The list has
+// 256 entries, so every word carries 8 bits.
+var pairingWords = [256]string{
+	"acorn", "adrift", "alpine", "amber", "anchor", "apple", "apron", "arctic", "arrow", "aspen",
+	"atlas", "august", "autumn", "badge", "bagel", "balcony", "bamboo", "banana", "banjo", "barley",
+	"basket", "beacon", "beetle", "berry", "bicycle", "biscuit", "blanket", "blossom", "bonnet",
+	"bouquet", "bramble", "breeze", "brick", "bridge", "bucket", "buffalo", "bundle", "butter",
+	"button", "cabin", "cactus", "camel", "candle", "canoe", "canyon", "carbon", "carrot", "castle",
+	"cedar", "cellar", "cereal", "chalk", "cherry", "circus", "citrus", "clover", "cobalt", "coconut",
+	"comet", "copper", "coral", "cotton", "cricket", "crystal", "cupcake", "cushion", "daisy",
+	"dancer", "delta", "denim", "desert", "diamond", "dolphin", "domino", "donkey", "dragon",
+	"drizzle", "dune", "eagle", "echo", "eclipse", "elbow", "ember", "emerald", "engine", "falcon",
+	"feather", "fennel", "ferry", "fiddle", "fig", "flannel", "fossil", "fountain", "fox", "galaxy",
+	"garden", "garlic", "gazelle", "geyser", "ginger", "glacier", "goblet", "granite", "grape",
+	"gravel", "guitar", "hammock", "harbor", "harvest", "hazel", "helmet", "heron", "honey",
+	"horizon", "husky", "igloo", "indigo", "island", "ivory", "jacket", "jaguar", "jasmine", "jelly",
+	"jigsaw", "jungle", "kayak", "kettle", "kiwi", "koala", "ladder", "lagoon", "lantern", "lava",
+	"lemon", "lettuce", "lilac", "lime", "linen", "lobster", "locket", "lotus", "magnet", "mango",
+	"maple", "marble", "meadow", "melon", "meteor", "mint", "mitten", "monsoon", "mosaic", "muffin",
+	"mustard", "nectar", "needle", "nickel", "noodle", "nutmeg", "oasis", "oatmeal", "ocean", "olive",
+	"onion", "orbit", "orchid", "otter", "oyster", "paddle", "pancake", "panda", "paper", "parrot",
+	"peach", "peanut", "pebble", "pelican", "pepper", "pickle", "pigeon", "pillow", "pine", "planet",
+	"plum", "pocket", "polar", "pony", "poppy", "potato", "pretzel", "prism", "puddle", "pumpkin",
+	"purple", "puzzle", "quartz", "quill", "rabbit", "radish", "raven", "ribbon", "river", "robin",
+	"rocket", "saddle", "saffron", "salmon", "sausage", "scarf", "seagull", "sesame", "shadow",
+	"shell", "silver", "sketch", "sparrow", "spinach", "sponge", "squash", "squirrel", "summit",
+	"sunset", "sushi", "swallow", "tango", "teapot", "thimble", "thistle", "thunder", "tiger",
+	"timber", "toast", "tomato", "topaz", "tractor", "trumpet", "tulip", "tundra", "turnip", "turtle",
+	"valley", "velvet", "violet", "volcano", "waffle", "walnut", "walrus", "wander", "willow",
+	"window", "winter", "wizard", "yogurt", "zebra", "zephyr",
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/pnet"
+)
+
+// The length of a swarm key in bytes.
+const swarmKeySize = 32
+
+// GenerateSwarmKey creates a random pre-shared key for a private
+// swarm in the format that go-ipfs and LoadSwarmKey understand.
+func GenerateSwarmKey() ([]byte, error) {
+	key := make([]byte, swarmKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("/key/swarm/psk/1.0.0/\n/base16/\n%s\n", hex.EncodeToString(key))), nil
+}
+
+// LoadSwarmKey reads the pre-shared key of a private swarm from
+// the given file.
+func LoadSwarmKey(path string) (pnet.PSK, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read swarm key: %w", err)
+	}
+
+	psk, err := pnet.DecodeV1PSK(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid swarm key %s: %w", path, err)
+	}
+
+	return psk, nil
+}
+
+// SwarmTag derives a short, public identifier of the private swarm
+// with the given key. It's appended to the mDNS service names, so
+// nodes of different swarms don't list each other.
+func SwarmTag(psk pnet.PSK) string {
+	sum := sha256.Sum256(append([]byte("p2p swarm tag\n"), psk...))
+	return hex.EncodeToString(sum[:4])
+}
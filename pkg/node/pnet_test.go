@@ -0,0 +1,77 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func writeSwarmKey(t *testing.T) string {
+	key, err := GenerateSwarmKey()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "swarm.key")
+	require.NoError(t, os.WriteFile(path, key, 0600))
+	return path
+}
+
+func TestLoadSwarmKey(t *testing.T) {
+	psk1, err := LoadSwarmKey(writeSwarmKey(t))
+	require.NoError(t, err)
+	assert.Len(t, psk1, swarmKeySize)
+
+	psk2, err := LoadSwarmKey(writeSwarmKey(t))
+	require.NoError(t, err)
+	assert.NotEqual(t, psk1, psk2)
+
+	assert.Len(t, SwarmTag(psk1), 8)
+	assert.Equal(t, SwarmTag(psk1), SwarmTag(psk1))
+	assert.NotEqual(t, SwarmTag(psk1), SwarmTag(psk2))
+}
+
+func TestLoadSwarmKey_invalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swarm.key")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+
+	_, err := LoadSwarmKey(path)
+	assert.Error(t, err)
+
+	_, err = LoadSwarmKey(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestBroadcastDiscoverer_handleBeacon_ignoresOtherSwarms(t *testing.T) {
+	receiver := localNode(t, "receiver")
+	receiver.SetMdnsServices(commons.MdnsServiceReceive)
+	receiver.MDNSProtocol.SwarmTag = "aaaaaaaa"
+
+	sender := localNode(t, "sender")
+	sender.SetMdnsServices("", commons.MdnsServiceReceive)
+	sender.MDNSProtocol.SwarmTag = "bbbbbbbb"
+	b := NewBroadcastDiscoverer(sender, 0)
+
+	beacon := func() []byte {
+		msg := p2p.NewBroadcastBeacon(receiver.serviceName(commons.MdnsServiceReceive))
+		for _, addr := range receiver.Addrs() {
+			msg.Addrs = append(msg.Addrs, addr.Bytes())
+		}
+		data, err := receiver.Marshal(msg)
+		require.NoError(t, err)
+		return data
+	}
+
+	b.handleBeacon(beacon())
+	assert.Empty(t, b.PeersList())
+
+	sender.MDNSProtocol.SwarmTag = "aaaaaaaa"
+	b.handleBeacon(beacon())
+	require.Len(t, b.PeersList(), 1)
+	service, _ := b.Service(receiver.ID())
+	assert.Equal(t, commons.MdnsServiceReceive, service)
+}
@@ -0,0 +1,204 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/format"
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolPull = "/p2p/pull/0.0.1"
+
+// ErrNotShared is returned to peers that request content which
+// isn't shared.
+var ErrNotShared = errors.New("the content isn't shared")
+
+// PullProtocol lets peers request content by its content ID and
+// streams it back to them.
+type PullProtocol struct {
+	node *Node
+	lk   sync.RWMutex
+	ph   PullHandler
+}
+
+// PullHandler decides which content peers may pull.
+type PullHandler interface {
+	// HandlePullRequest returns the path of the file with the given
+	// content ID if the given peer may pull it.
+	HandlePullRequest(peerID peer.ID, c cid.Cid) (string, error)
+}
+
+// NewPullProtocol initializes a new PullProtocol object. Peers can't
+// pull anything until a handler is registered, which only p2p share does.
+func NewPullProtocol(node *Node) *PullProtocol {
+	return &PullProtocol{node: node, lk: sync.RWMutex{}}
+}
+
+func (p *PullProtocol) RegisterPullHandler(ph PullHandler) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.ph = ph
+	p.node.SetStreamHandler(ProtocolPull, p.onPullRequest)
+}
+
+func (p *PullProtocol) UnregisterPullHandler() {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.node.RemoveStreamHandler(ProtocolPull)
+	p.ph = nil
+}
+
+func (p *PullProtocol) onPullRequest(s network.Stream) {
+	defer s.Close()
+
+	p.lk.RLock()
+	ph := p.ph
+	p.lk.RUnlock()
+
+	if ph == nil {
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+
+	req := &p2p.PullRequest{}
+	if err := p.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	// The stream is authenticated, unlike the header of the request.
+	peerID := s.Conn().RemotePeer()
+
+	c, err := cid.Cast(req.Cid)
+	if err != nil {
+		p.refuse(s, err)
+		return
+	}
+
+	path, err := ph.HandlePullRequest(peerID, c)
+	if err != nil {
+		p.refuse(s, err)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Infoln(err)
+		p.refuse(s, ErrNotShared)
+		return
+	}
+	defer f.Close()
+
+	fstat, err := f.Stat()
+	if err != nil {
+		log.Infoln(err)
+		p.refuse(s, ErrNotShared)
+		return
+	}
+
+	resp := p2p.NewPullResponse(nil)
+	resp.Filename = filepath.Base(path)
+	resp.Size = fstat.Size()
+	if err = p.node.WriteMessage(s, resp); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	// The file may have grown since, so only send what we announced.
+	sent, err := io.CopyN(s, f, resp.Size)
+	if err == nil {
+		err = s.CloseWrite()
+	}
+
+	if err != nil {
+		log.Infof("Sending %s to %s failed after %s: %s\n", resp.Filename, peerID, format.Bytes(sent), err)
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+	log.Infof("Sent %s (%s) to %s\n", resp.Filename, format.Bytes(sent), peerID)
+}
+
+// refuse tells the peer why its pull request failed.
+func (p *PullProtocol) refuse(s network.Stream, reason error) {
+	if err := p.node.WriteMessage(s, p2p.NewPullResponse(reason)); err != nil {
+		log.Infoln(err)
+	}
+}
+
+// Content is the data that a peer streams back for a pull request.
+// Reading it fails if the data doesn't have the requested content ID.
+type Content struct {
+	io.Reader
+
+	// The name and size of the file as announced by the peer.
+	Filename string
+	Size     int64
+
+	s network.Stream
+}
+
+// Close closes the stream the content is read from.
+func (c *Content) Close() error {
+	return c.s.Close()
+}
+
+// Pull requests the content with the given content ID from the given
+// peer. The caller needs to close the returned content.
+func (p *PullProtocol) Pull(ctx context.Context, peerID peer.ID, c cid.Cid) (*Content, error) {
+
+	s, err := p.node.NewStream(withRelayedConns(ctx, "pull"), peerID, ProtocolPull)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := p.readContent(s, peerID, c)
+	if err != nil {
+		if err2 := s.Reset(); err2 != nil {
+			log.Infoln(err2)
+		}
+		return nil, err
+	}
+	return content, nil
+}
+
+func (p *PullProtocol) readContent(s network.Stream, peerID peer.ID, c cid.Cid) (*Content, error) {
+	if err := p.node.Send(s, p2p.NewPullRequest(c)); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(s)
+	resp := &p2p.PullResponse{}
+	if err := p.node.ReadMessage(br, resp); err != nil {
+		return nil, err
+	}
+
+	if author, err := resp.PeerID(); err != nil || author != peerID {
+		return nil, fmt.Errorf("received pull response of unexpected peer %q", resp.GetHeader().GetNodeId())
+	} else if !resp.Ok {
+		return nil, fmt.Errorf("peer %s refused to send %s: %s", peerID, c, resp.Error)
+	}
+
+	r, err := NewVerifiedReader(io.LimitReader(br, resp.Size), c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Content{Reader: r, Filename: resp.Filename, Size: resp.Size, s: s}, nil
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedFiles is a PullHandler that shares the given files with
+// everyone.
+type sharedFiles map[string]string
+
+func (f sharedFiles) HandlePullRequest(_ peer.ID, c cid.Cid) (string, error) {
+	path, found := f[c.String()]
+	if !found {
+		return "", ErrNotShared
+	}
+	return path, nil
+}
+
+// writeFile writes the given content into a temporary file and
+// returns its path and content ID.
+func writeFile(t *testing.T, name string, content string) (string, cid.Cid) {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	c, err := FileContentID(path)
+	require.NoError(t, err)
+	return path, c
+}
+
+func TestPullProtocol_Pull(t *testing.T) {
+	path, c := writeFile(t, "report.txt", "quarterly numbers")
+	n1, n2 := nodePair(t, nil)
+	n2.RegisterPullHandler(sharedFiles{c.String(): path})
+
+	content, err := n1.Pull(context.Background(), n2.ID(), c)
+	require.NoError(t, err)
+	defer content.Close()
+
+	assert.Equal(t, "report.txt", content.Filename)
+	assert.EqualValues(t, 17, content.Size)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "quarterly numbers", string(data))
+}
+
+func TestPullProtocol_Pull_notShared(t *testing.T) {
+	_, c := writeFile(t, "report.txt", "quarterly numbers")
+	n1, n2 := nodePair(t, nil)
+	n2.RegisterPullHandler(sharedFiles{})
+
+	_, err := n1.Pull(context.Background(), n2.ID(), c)
+	assert.ErrorContains(t, err, ErrNotShared.Error())
+}
+
+func TestPullProtocol_Pull_changedContent(t *testing.T) {
+	path, c := writeFile(t, "report.txt", "quarterly numbers")
+	n1, n2 := nodePair(t, nil)
+	n2.RegisterPullHandler(sharedFiles{c.String(): path})
+	require.NoError(t, os.WriteFile(path, []byte("quarterly Numbers"), 0644))
+
+	content, err := n1.Pull(context.Background(), n2.ID(), c)
+	require.NoError(t, err)
+	defer content.Close()
+
+	_, err = io.ReadAll(content)
+	assert.ErrorContains(t, err, "instead of")
+}
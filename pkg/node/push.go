@@ -2,9 +2,10 @@ package node
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
-	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -16,11 +17,26 @@ import (
 // pattern: /protocol-name/request-or-response-message/version
 const ProtocolPushRequest = "/p2p/push/0.0.1"
 
+// The number of push requests a peer may send within pushRateWindow.
+// Peers that send more are blocked for pushBanDuration.
+var (
+	pushRateLimit   = 5
+	pushRateWindow  = time.Minute
+	pushBanDuration = 10 * time.Minute
+)
+
+// ErrHaveContent is returned by push request handlers if they already
+// have the content, and by SendPushRequest if the peer has it. The
+// transfer is skipped then.
+var ErrHaveContent = errors.New("the peer already has the content")
+
 // PushProtocol type
 type PushProtocol struct {
 	node *Node
 	lk   sync.RWMutex
 	prh  PushRequestHandler
+
+	limiter *rateLimiter
 }
 
 type PushRequestHandler interface {
@@ -31,6 +47,7 @@ type PushRequestHandler interface {
 func NewPushProtocol(node *Node) *PushProtocol {
 	return &PushProtocol{
 		node: node, lk: sync.RWMutex{},
+		limiter: newRateLimiter(pushRateLimit, pushRateWindow),
 	}
 }
 
@@ -52,22 +69,44 @@ func (p *PushProtocol) onPushRequest(s network.Stream) {
 
 	defer s.Close()
 
+	peerID := s.Conn().RemotePeer()
+	if !p.limiter.Allow(peerID) {
+		log.Infof("Peer %s sent too many requests and is blocked for %s\n", peerID, pushBanDuration)
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		p.node.Block(peerID, appTime.Now().Add(pushBanDuration))
+		return
+	}
+
 	req := &p2p.PushRequest{}
 	if err := p.node.Read(s, req); err != nil {
 		log.Infoln(err)
 		return
 	}
 
+	// Signed requests can be replayed by anyone, so they only count
+	// if their author is the peer on the other end of the stream.
+	if author, err := req.PeerID(); err != nil || author != peerID {
+		log.Infof("Dropped push request of %s that it didn't author\n", peerID)
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+
 	p.lk.RLock()
 	defer p.lk.RUnlock()
 	accept, err := p.prh.HandlePushRequest(req)
-	if err != nil {
+	have := errors.Is(err, ErrHaveContent)
+	if err != nil && !have {
 		log.Infoln(err)
-		accept = false
 		// Fall through and tell peer we won't handle the request
 	}
 
-	if err := p.node.Send(s, p2p.NewPushResponse(accept)); err != nil {
+	resp := p2p.NewPushResponse(accept && err == nil)
+	resp.Have = have
+	if err := p.node.Send(s, resp); err != nil {
 		log.Infoln(err)
 		return
 	}
@@ -78,15 +117,18 @@ func (p *PushProtocol) onPushRequest(s network.Stream) {
 	}
 }
 
-func (p *PushProtocol) SendPushRequest(ctx context.Context, peerID peer.ID, filename string, size int64, c cid.Cid) (bool, error) {
+// SendPushRequest sends the given request to the given peer and
+// returns whether it was accepted. It returns ErrHaveContent if the
+// peer already has the content.
+func (p *PushProtocol) SendPushRequest(ctx context.Context, peerID peer.ID, req *p2p.PushRequest) (bool, error) {
 
-	s, err := p.node.NewStream(ctx, peerID, ProtocolPushRequest)
+	s, err := p.node.NewStream(withRelayedConns(ctx, "push request"), peerID, ProtocolPushRequest)
 	if err != nil {
 		return false, err
 	}
 	defer s.Close()
 
-	if err = p.node.Send(s, p2p.NewPushRequest(filename, size, c)); err != nil {
+	if err = p.node.Send(s, req); err != nil {
 		return false, err
 	}
 
@@ -95,5 +137,8 @@ func (p *PushProtocol) SendPushRequest(ctx context.Context, peerID peer.ID, file
 		return false, err
 	}
 
+	if resp.Have {
+		return false, ErrHaveContent
+	}
 	return resp.Accept, nil
 }
@@ -1,10 +1,15 @@
 package node
 
 import (
+	"context"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
 )
 
 func mockNode(t *testing.T) *Node {
@@ -14,3 +19,52 @@ func mockNode(t *testing.T) *Node {
 
 	return &Node{Host: h}
 }
+
+type havingPushHandler struct{}
+
+func (havingPushHandler) HandlePushRequest(*p2p.PushRequest) (bool, error) {
+	return true, ErrHaveContent
+}
+
+func TestPushProtocol_SendPushRequest_haveContent(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+	receiver.RegisterRequestHandler(havingPushHandler{})
+
+	accepted, err := sender.SendPushRequest(context.Background(), receiver.ID(), p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+	assert.ErrorIs(t, err, ErrHaveContent)
+	assert.False(t, accepted)
+}
+
+func TestPushProtocol_SendPushRequest_handlerError(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+	receiver.RegisterRequestHandler(failingPushHandler{})
+
+	accepted, err := sender.SendPushRequest(context.Background(), receiver.ID(), p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+	require.NoError(t, err)
+	assert.False(t, accepted)
+}
+
+type failingPushHandler struct{}
+
+func (failingPushHandler) HandlePushRequest(*p2p.PushRequest) (bool, error) {
+	return true, assert.AnError
+}
+
+func TestPushProtocol_onPushRequest_replayed(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+	handler := acceptPushRequests(receiver)
+
+	// A request that another node signed, e.g. one it sent earlier.
+	data, err := localNode(t, "author").Marshal(p2p.NewPushRequest("file.txt", 1, cid.Cid{}))
+	require.NoError(t, err)
+
+	s, err := sender.NewStream(context.Background(), receiver.ID(), ProtocolPushRequest)
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, s.CloseWrite())
+
+	assert.Error(t, sender.Read(s, &p2p.PushResponse{}))
+	assert.Empty(t, handler.requests)
+}
@@ -0,0 +1,234 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+)
+
+// The time before the expiration at which a relay reservation is refreshed.
+var relayRefreshMargin = time.Minute
+
+// The time to wait before retrying a failed reservation.
+var relayRetryInterval = 10 * time.Second
+
+// RelayLimits restrict the resources a single relayed connection
+// may use. Zero values mean unlimited.
+type RelayLimits struct {
+	// The number of bytes relayed in each direction.
+	MaxBytes int64
+
+	// The time after which the relayed connection is reset.
+	MaxDuration time.Duration
+}
+
+// NewRelayService starts a circuit relay v2 service on the given node.
+// It relays connections between peers that can't reach each other
+// directly, e.g. because they are in different Wi-Fi isolation zones.
+func NewRelayService(node *Node, limits RelayLimits) (*relay.Relay, error) {
+	rc := relay.DefaultResources()
+	rc.Limit = nil
+	if limits.MaxBytes > 0 || limits.MaxDuration > 0 {
+		rc.Limit = &relay.RelayLimit{Data: limits.MaxBytes, Duration: limits.MaxDuration}
+		if rc.Limit.Data <= 0 {
+			rc.Limit.Data = math.MaxInt64
+		}
+		if rc.Limit.Duration <= 0 {
+			// The limit is transmitted in seconds as uint32.
+			rc.Limit.Duration = math.MaxUint32 * time.Second
+		}
+	}
+
+	return relay.New(node.Host, relay.WithResources(rc))
+}
+
+// RelayProtocol connects to peers via a circuit relay and makes
+// the node reachable through it.
+type RelayProtocol struct {
+	node *Node
+
+	lk          sync.RWMutex // protects relay, reservation and cancel
+	relay       *peer.AddrInfo
+	reservation *client.Reservation
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// UseRelay configures the relay at the given multiaddress including
+// the /p2p/ component. Peers that are connected afterwards can also
+// be reached through it.
+func (r *RelayProtocol) UseRelay(addr string) error {
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return fmt.Errorf("invalid relay %q: %w", addr, err)
+	}
+
+	pi, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return fmt.Errorf("invalid relay %q: %w", addr, err)
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.relay = pi
+
+	return nil
+}
+
+// ReserveRelay reserves a slot at the configured relay, so other peers
+// can connect through it. The relayed addresses are added to the
+// addresses of the node and the reservation is refreshed in the
+// background until StopRelay is called.
+func (r *RelayProtocol) ReserveRelay(ctx context.Context) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.relay == nil {
+		return fmt.Errorf("no relay configured")
+	} else if r.cancel != nil {
+		return nil
+	}
+
+	rsvp, err := client.Reserve(ctx, r.node.Host, *r.relay)
+	if err != nil {
+		return fmt.Errorf("failed to reserve a slot at the relay: %w", err)
+	}
+	r.reservation = rsvp
+
+	refreshCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.refresh(refreshCtx, rsvp.Expiration)
+	}()
+
+	return nil
+}
+
+// refresh renews the reservation shortly before it expires.
+func (r *RelayProtocol) refresh(ctx context.Context, expiration time.Time) {
+	r.lk.RLock()
+	relayInfo := *r.relay
+	r.lk.RUnlock()
+
+	for {
+		wait := expiration.Sub(appTime.Now()) - relayRefreshMargin
+		if wait < relayRetryInterval {
+			wait = relayRetryInterval
+		}
+
+		t := appTime.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		rsvp, err := client.Reserve(ctx, r.node.Host, relayInfo)
+		if err != nil {
+			log.Infoln("Failed to refresh relay reservation:", err)
+			expiration = appTime.Now()
+			continue
+		}
+
+		r.lk.Lock()
+		r.reservation = rsvp
+		r.lk.Unlock()
+		expiration = rsvp.Expiration
+	}
+}
+
+// StopRelay stops refreshing the relay reservation. The node isn't
+// reachable through the relay anymore once the reservation expires.
+func (r *RelayProtocol) StopRelay() {
+	r.lk.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.reservation = nil
+	r.lk.Unlock()
+
+	if cancel != nil {
+		cancel()
+		r.wg.Wait()
+	}
+}
+
+// RelayAddrs returns the addresses at which the node is reachable
+// through the relay. It's empty if no slot is reserved.
+func (r *RelayProtocol) RelayAddrs() []ma.Multiaddr {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
+	if r.reservation == nil {
+		return nil
+	}
+
+	return circuitAddrs(*r.relay)
+}
+
+// ViaRelay returns the given address information extended by the
+// addresses that reach the peer through the configured relay.
+func (r *RelayProtocol) ViaRelay(pi peer.AddrInfo) peer.AddrInfo {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
+	if r.relay == nil || r.relay.ID == pi.ID {
+		return pi
+	}
+
+	pi.Addrs = append(append([]ma.Multiaddr(nil), pi.Addrs...), circuitAddrs(*r.relay)...)
+	return pi
+}
+
+// addrsFactory adds the relayed addresses to the given
+// addresses the node listens on.
+func (r *RelayProtocol) addrsFactory(addrs []ma.Multiaddr) []ma.Multiaddr {
+	return append(addrs, r.RelayAddrs()...)
+}
+
+// IsRelayed returns true if the node is only connected to the
+// given peer through a relay.
+func (n *Node) IsRelayed(peerID peer.ID) bool {
+	conns := n.Network().ConnsToPeer(peerID)
+	for _, conn := range conns {
+		if !isRelayAddr(conn.RemoteMultiaddr()) {
+			return false
+		}
+	}
+	return len(conns) > 0
+}
+
+// circuitAddrs returns the addresses of the given relay that
+// are used to establish relayed connections.
+func circuitAddrs(relayInfo peer.AddrInfo) []ma.Multiaddr {
+	circuit := ma.StringCast(fmt.Sprintf("/p2p/%s/p2p-circuit", relayInfo.ID))
+	addrs := make([]ma.Multiaddr, 0, len(relayInfo.Addrs))
+	for _, addr := range relayInfo.Addrs {
+		addrs = append(addrs, addr.Encapsulate(circuit))
+	}
+	return addrs
+}
+
+func isRelayAddr(addr ma.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(ma.P_CIRCUIT)
+	return err == nil
+}
+
+// withRelayedConns allows opening streams over connections to relays
+// that limit the duration or amount of data of relayed connections.
+func withRelayedConns(ctx context.Context, reason string) context.Context {
+	return network.WithUseTransient(ctx, reason)
+}
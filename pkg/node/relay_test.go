@@ -0,0 +1,94 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferTransferHandler collects the transferred data of a single peer.
+type bufferTransferHandler struct {
+	peerID peer.ID
+	limit  int64
+	lk     sync.Mutex
+	buf    bytes.Buffer
+	done   chan struct{}
+
+	// Returned instead of the error of reading, if set.
+	fail error
+
+	// Whether the handler claims to have verified the data.
+	verified bool
+}
+
+func (b *bufferTransferHandler) HandleTransfer(r io.Reader) (bool, error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	defer close(b.done)
+	_, err := io.Copy(&b.buf, r)
+	if b.fail != nil {
+		return false, b.fail
+	}
+	return b.verified, err
+}
+
+func (b *bufferTransferHandler) GetLimit() int64 {
+	return b.limit
+}
+
+func (b *bufferTransferHandler) GetPeerID() peer.ID {
+	return b.peerID
+}
+
+// relayNode starts a relay with the given limits.
+func relayNode(t *testing.T, limits RelayLimits) *Node {
+	r := localNode(t, "relay")
+	service, err := NewRelayService(r, limits)
+	require.NoError(t, err)
+	t.Cleanup(func() { service.Close() })
+	return r
+}
+
+func TestRelay_transfersThroughRelay(t *testing.T) {
+	sender, receiver := nodePair(t, relayNode(t, RelayLimits{}))
+
+	info, err := sender.RequestInfo(context.Background(), receiver.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "receiver", info.Nickname)
+
+	payload := bytes.Repeat([]byte("p2p"), 100_000)
+	th := &bufferTransferHandler{peerID: sender.ID(), limit: int64(len(payload)), done: make(chan struct{})}
+	receiver.RegisterTransferHandler(th)
+
+	n, _, err := sender.Transfer(context.Background(), receiver.ID(), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.EqualValues(t, len(payload), n)
+
+	<-th.done
+	assert.Equal(t, payload, th.buf.Bytes())
+}
+
+func TestRelay_enforcesLimits(t *testing.T) {
+	sender, receiver := nodePair(t, relayNode(t, RelayLimits{MaxBytes: 64 << 10, MaxDuration: time.Minute}))
+
+	payload := bytes.Repeat([]byte("p2p"), 100_000)
+	th := &bufferTransferHandler{peerID: sender.ID(), limit: int64(len(payload)), done: make(chan struct{})}
+	receiver.RegisterTransferHandler(th)
+
+	_, _, err := sender.Transfer(context.Background(), receiver.ID(), bytes.NewReader(payload))
+	assert.ErrorIs(t, err, ErrRelayLimit)
+
+	select {
+	case <-th.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relayed transfer wasn't cut off")
+	}
+	assert.Less(t, th.buf.Len(), len(payload))
+}
@@ -0,0 +1,510 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolRendezvous = "/p2p/rendezvous/0.0.1"
+
+// Registration limits of the rendezvous server.
+var (
+	rendezvousDefaultTTL = 2 * time.Minute
+	rendezvousMaxTTL     = 2 * time.Hour
+	rendezvousMaxAddrs   = 32
+
+	// The number of namespaces and the number of registrations per
+	// namespace the server keeps at most.
+	rendezvousMaxNamespaces    = 1024
+	rendezvousMaxRegistrations = 1024
+)
+
+// The time between two removals of expired registrations.
+var rendezvousSweepInterval = time.Minute
+
+// The time between two queries of the rendezvous server.
+var rendezvousPollInterval = 5 * time.Second
+
+// RendezvousServer keeps track of the nodes that registered under
+// a namespace and answers queries for them. This allows nodes in
+// different subnets to find each other where mDNS doesn't reach.
+type RendezvousServer struct {
+	node *Node
+	lk   sync.Mutex // protects registrations and sweeper
+	// namespace -> peer -> registration
+	registrations map[string]map[peer.ID]*registration
+	sweeper       *time.Timer
+}
+
+type registration struct {
+	addrs   []ma.Multiaddr
+	expires time.Time
+}
+
+// NewRendezvousServer initializes a new RendezvousServer and
+// registers its stream handler.
+func NewRendezvousServer(node *Node) *RendezvousServer {
+	r := &RendezvousServer{
+		node:          node,
+		registrations: map[string]map[peer.ID]*registration{},
+	}
+	r.sweeper = appTime.AfterFunc(rendezvousSweepInterval, r.sweep)
+	node.SetStreamHandler(ProtocolRendezvous, r.onRendezvousRequest)
+	return r
+}
+
+// Close stops answering rendezvous requests.
+func (r *RendezvousServer) Close() {
+	r.node.RemoveStreamHandler(ProtocolRendezvous)
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if r.sweeper != nil {
+		r.sweeper.Stop()
+		r.sweeper = nil
+	}
+}
+
+// sweep removes the expired registrations of all namespaces and
+// schedules the next sweep until the server is closed.
+func (r *RendezvousServer) sweep() {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.sweeper == nil {
+		return
+	}
+
+	now := appTime.Now()
+	for namespace := range r.registrations {
+		r.expire(namespace, now)
+	}
+	r.sweeper = appTime.AfterFunc(rendezvousSweepInterval, r.sweep)
+}
+
+// expire removes the registrations of the given namespace that
+// expired before now and the namespace itself if it's empty then.
+// The caller must hold the lock.
+func (r *RendezvousServer) expire(namespace string, now time.Time) {
+	for peerID, reg := range r.registrations[namespace] {
+		if now.After(reg.expires) {
+			delete(r.registrations[namespace], peerID)
+		}
+	}
+	if len(r.registrations[namespace]) == 0 {
+		delete(r.registrations, namespace)
+	}
+}
+
+func (r *RendezvousServer) onRendezvousRequest(s network.Stream) {
+	defer s.Close()
+
+	req := &p2p.RendezvousRequest{}
+	if err := r.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	resp := r.handleRequest(s.Conn().RemotePeer(), req)
+	if err := r.node.Send(s, resp); err != nil {
+		log.Infoln(err)
+		return
+	}
+}
+
+func (r *RendezvousServer) handleRequest(remote peer.ID, req *p2p.RendezvousRequest) *p2p.RendezvousResponse {
+	// Nodes may only register themselves.
+	author, err := req.PeerID()
+	if err != nil {
+		return p2p.NewRendezvousResponse(err)
+	} else if author != remote {
+		return p2p.NewRendezvousResponse(fmt.Errorf("request authored by %s but sent by %s", author, remote))
+	}
+
+	if req.Namespace == "" {
+		return p2p.NewRendezvousResponse(fmt.Errorf("namespace is empty"))
+	}
+
+	switch req.Type {
+	case p2p.RendezvousRequest_REGISTER:
+		return p2p.NewRendezvousResponse(r.register(author, req))
+	case p2p.RendezvousRequest_UNREGISTER:
+		r.unregister(author, req.Namespace)
+		return p2p.NewRendezvousResponse(nil)
+	case p2p.RendezvousRequest_DISCOVER:
+		resp := p2p.NewRendezvousResponse(nil)
+		resp.Registrations = r.discover(author, req.Namespace)
+		return resp
+	default:
+		return p2p.NewRendezvousResponse(fmt.Errorf("unknown request type %d", req.Type))
+	}
+}
+
+func (r *RendezvousServer) register(peerID peer.ID, req *p2p.RendezvousRequest) error {
+	if len(req.Addrs) == 0 || len(req.Addrs) > rendezvousMaxAddrs {
+		return fmt.Errorf("expected between 1 and %d addresses", rendezvousMaxAddrs)
+	}
+
+	addrs := make([]ma.Multiaddr, 0, len(req.Addrs))
+	for _, b := range req.Addrs {
+		addr, err := ma.NewMultiaddrBytes(b)
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, addr)
+	}
+
+	ttl := time.Duration(req.Ttl) * time.Second
+	if ttl <= 0 {
+		ttl = rendezvousDefaultTTL
+	} else if ttl > rendezvousMaxTTL {
+		ttl = rendezvousMaxTTL
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	now := appTime.Now()
+	r.expire(req.Namespace, now)
+
+	ns, found := r.registrations[req.Namespace]
+	if !found {
+		if len(r.registrations) >= rendezvousMaxNamespaces {
+			// Expired registrations of other namespaces may make room.
+			for namespace := range r.registrations {
+				r.expire(namespace, now)
+			}
+		}
+		if len(r.registrations) >= rendezvousMaxNamespaces {
+			return fmt.Errorf("the server has no room for more namespaces")
+		}
+		ns = map[peer.ID]*registration{}
+		r.registrations[req.Namespace] = ns
+	} else if _, renewal := ns[peerID]; !renewal && len(ns) >= rendezvousMaxRegistrations {
+		return fmt.Errorf("namespace %s has no room for more registrations", req.Namespace)
+	}
+	ns[peerID] = &registration{addrs: addrs, expires: now.Add(ttl)}
+
+	return nil
+}
+
+func (r *RendezvousServer) unregister(peerID peer.ID, namespace string) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	delete(r.registrations[namespace], peerID)
+	if len(r.registrations[namespace]) == 0 {
+		delete(r.registrations, namespace)
+	}
+}
+
+// discover returns all valid registrations of the given namespace
+// except the one of the requesting peer.
+func (r *RendezvousServer) discover(requester peer.ID, namespace string) []*p2p.RendezvousRegistration {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.expire(namespace, appTime.Now())
+
+	regs := []*p2p.RendezvousRegistration{}
+	for peerID, reg := range r.registrations[namespace] {
+		if peerID == requester {
+			continue
+		}
+
+		pbReg := &p2p.RendezvousRegistration{PeerId: peerID.String()}
+		for _, addr := range reg.addrs {
+			pbReg.Addrs = append(pbReg.Addrs, addr.Bytes())
+		}
+		regs = append(regs, pbReg)
+	}
+
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].PeerId < regs[j].PeerId
+	})
+
+	return regs
+}
+
+// RendezvousDiscoverer registers the node at a rendezvous server and
+// regularly queries it for other nodes. It uses the mDNS service names
+// of the node within the configured namespace, so senders still only
+// find receivers.
+type RendezvousDiscoverer struct {
+	peerEvents
+	node      *Node
+	server    peer.AddrInfo
+	namespace string
+	peers     *sync.Map
+
+	lk         sync.Mutex // protects cancel and registered
+	cancel     context.CancelFunc
+	registered string
+	wg         sync.WaitGroup
+}
+
+// NewRendezvousDiscoverer creates a RendezvousDiscoverer for the
+// server at the given multiaddress including the /p2p/ component.
+func NewRendezvousDiscoverer(node *Node, server string, namespace string) (*RendezvousDiscoverer, error) {
+	addr, err := ma.NewMultiaddr(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rendezvous server %q: %w", server, err)
+	}
+
+	pi, err := peer.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rendezvous server %q: %w", server, err)
+	}
+
+	return &RendezvousDiscoverer{
+		node:      node,
+		server:    *pi,
+		namespace: namespace,
+		peers:     &sync.Map{},
+	}, nil
+}
+
+// Start registers the node and starts querying the rendezvous
+// server in the background.
+func (r *RendezvousDiscoverer) Start(ctx context.Context) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.cancel != nil {
+		return nil
+	}
+
+	if err := r.node.Connect(ctx, r.server); err != nil {
+		return fmt.Errorf("failed to connect to rendezvous server: %w", err)
+	}
+
+	if r.node.MdnsAdvertise != "" {
+		ns := r.nsFor(r.node.MdnsAdvertise)
+		if err := r.Register(ctx, ns, rendezvousDefaultTTL); err != nil {
+			return err
+		}
+		r.registered = ns
+	}
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			r.poll(pollCtx)
+
+			t := appTime.NewTimer(rendezvousPollInterval)
+			select {
+			case <-pollCtx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop unregisters the node and stops querying the rendezvous server.
+func (r *RendezvousDiscoverer) Stop() error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+	r.wg.Wait()
+	r.cancel = nil
+
+	var err error
+	if r.registered != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), staticProbeTimeout)
+		defer cancel()
+		err = r.request(ctx, p2p.NewRendezvousRequest(p2p.RendezvousRequest_UNREGISTER, r.registered), nil)
+		r.registered = ""
+	}
+
+	r.peers.Range(func(key, value interface{}) bool {
+		r.peers.Delete(key)
+		r.emit(PeerEvent{Type: PeerLeft, Peer: value.(*PeerInfo).pi})
+		return true
+	})
+
+	return err
+}
+
+// PeersList returns the peers of the last query sorted by their ID.
+func (r *RendezvousDiscoverer) PeersList() []peer.AddrInfo {
+	peers := []peer.AddrInfo{}
+	r.peers.Range(func(key, value interface{}) bool {
+		peers = append(peers, value.(*PeerInfo).pi)
+		return true
+	})
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+
+	return peers
+}
+
+// PeerInfo returns the stored information about the given peer.
+func (r *RendezvousDiscoverer) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	p, ok := r.peers.Load(peerID)
+	if !ok {
+		return nil, false
+	}
+	return p.(*PeerInfo), true
+}
+
+// Register registers the node's dialable addresses under the given namespace.
+func (r *RendezvousDiscoverer) Register(ctx context.Context, namespace string, ttl time.Duration) error {
+	req := p2p.NewRendezvousRequest(p2p.RendezvousRequest_REGISTER, namespace)
+	req.Ttl = int64(ttl.Seconds())
+	for _, addr := range r.node.Addrs() {
+		req.Addrs = append(req.Addrs, addr.Bytes())
+	}
+
+	return r.request(ctx, req, nil)
+}
+
+// Discover queries the peers registered under the given namespace.
+func (r *RendezvousDiscoverer) Discover(ctx context.Context, namespace string) ([]peer.AddrInfo, error) {
+	resp := &p2p.RendezvousResponse{}
+	err := r.request(ctx, p2p.NewRendezvousRequest(p2p.RendezvousRequest_DISCOVER, namespace), resp)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := []peer.AddrInfo{}
+	for _, reg := range resp.Registrations {
+		peerID, err := peer.Decode(reg.PeerId)
+		if err != nil {
+			continue
+		}
+
+		pi := peer.AddrInfo{ID: peerID}
+		for _, b := range reg.Addrs {
+			if addr, err := ma.NewMultiaddrBytes(b); err == nil {
+				pi.Addrs = append(pi.Addrs, addr)
+			}
+		}
+		peers = append(peers, pi)
+	}
+
+	return peers, nil
+}
+
+// request sends the given request to the rendezvous server and
+// parses the answer into resp if it's not nil.
+func (r *RendezvousDiscoverer) request(ctx context.Context, req *p2p.RendezvousRequest, resp *p2p.RendezvousResponse) error {
+	s, err := r.node.NewStream(ctx, r.server.ID, ProtocolRendezvous)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err = r.node.Send(s, req); err != nil {
+		return err
+	}
+
+	if resp == nil {
+		resp = &p2p.RendezvousResponse{}
+	}
+	if err = r.node.Read(s, resp); err != nil {
+		return err
+	}
+
+	if !resp.Ok {
+		return fmt.Errorf("rendezvous server: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// poll refreshes the registration and replaces the list of peers
+// with the current registrations of the browsed namespaces.
+func (r *RendezvousDiscoverer) poll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, staticProbeTimeout)
+	defer cancel()
+
+	// registered is only written while the polling routine isn't running.
+	if r.registered != "" {
+		if err := r.Register(ctx, r.registered, rendezvousDefaultTTL); err != nil {
+			log.Infoln(err)
+		}
+	}
+
+	found := map[peer.ID]peer.AddrInfo{}
+	for _, service := range r.node.MdnsBrowse {
+		peers, err := r.Discover(ctx, r.nsFor(service))
+		if err != nil {
+			// Keep the current list if the server is unreachable.
+			return
+		}
+		for _, pi := range peers {
+			found[pi.ID] = pi
+		}
+	}
+
+	for _, pi := range found {
+		if _, ok := r.peers.Load(pi.ID); ok {
+			continue
+		}
+		p := NewPeerInfo(pi, SourceRendezvous)
+		r.peers.Store(pi.ID, p)
+		r.emit(PeerEvent{Type: PeerJoined, Peer: pi})
+		if r.node.InfoProtocol != nil {
+			go r.updatePeerInfo(p)
+		}
+	}
+
+	r.peers.Range(func(key, value interface{}) bool {
+		if _, ok := found[key.(peer.ID)]; !ok {
+			r.peers.Delete(key)
+			r.emit(PeerEvent{Type: PeerLeft, Peer: value.(*PeerInfo).pi})
+		}
+		return true
+	})
+}
+
+// nsFor returns the rendezvous namespace of the given mDNS service name.
+func (r *RendezvousDiscoverer) nsFor(service string) string {
+	return r.namespace + "/" + service
+}
+
+// UseRendezvous creates a RendezvousDiscoverer for the given server and
+// adds it to the node's Discovery. Receivers that don't start the
+// Discovery can start the returned discoverer to get registered.
+func (n *Node) UseRendezvous(server string, namespace string) (*RendezvousDiscoverer, error) {
+	r, err := NewRendezvousDiscoverer(n, server, namespace)
+	if err != nil {
+		return nil, err
+	}
+	n.Discovery.Add(r)
+	return r, nil
+}
+
+// updatePeerInfo queries the information of the given peer and announces
+// the update to the subscribers.
+func (r *RendezvousDiscoverer) updatePeerInfo(p *PeerInfo) {
+	if r.node.fillPeerInfo(p) {
+		r.emit(PeerEvent{Type: PeerUpdated, Peer: p.pi})
+	}
+}
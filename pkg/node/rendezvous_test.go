@@ -0,0 +1,141 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestRendezvous_senderFindsReceiver(t *testing.T) {
+	defer func(interval time.Duration) { rendezvousPollInterval = interval }(rendezvousPollInterval)
+	rendezvousPollInterval = 50 * time.Millisecond
+
+	srv := localNode(t, "server")
+	server := NewRendezvousServer(srv)
+	defer server.Close()
+
+	addrs, err := srv.DialableAddrs()
+	require.NoError(t, err)
+
+	receiver := localNode(t, "receiver")
+	receiver.SetMdnsServices(commons.MdnsServiceReceive)
+	rdvReceiver, err := receiver.UseRendezvous(addrs[0].String(), "test")
+	require.NoError(t, err)
+	require.NoError(t, rdvReceiver.Start(context.Background()))
+
+	sender := localNode(t, "sender")
+	sender.SetMdnsServices(commons.MdnsServiceSend, commons.MdnsServiceReceive)
+	_, err = sender.UseRendezvous(addrs[0].String(), "test")
+	require.NoError(t, err)
+	require.NoError(t, sender.Discovery.Start(context.Background()))
+	defer sender.Discovery.Stop()
+
+	assert.Eventually(t, func() bool {
+		p, found := sender.Discovery.PeerInfo(receiver.ID())
+		return found && p.Info() != nil && p.Info().Nickname == "receiver"
+	}, 3*time.Second, 10*time.Millisecond)
+	p, _ := sender.Discovery.PeerInfo(receiver.ID())
+	assert.Equal(t, SourceRendezvous, p.Source())
+
+	// The receiver unregisters and disappears from the list.
+	require.NoError(t, rdvReceiver.Stop())
+	assert.Eventually(t, func() bool {
+		return len(sender.Discovery.PeersList()) == 0
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestRendezvousServer_handleRequest(t *testing.T) {
+	n := mockNode(t)
+	r := NewRendezvousServer(n)
+
+	self := n.ID()
+	other, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+
+	register := func(author peer.ID, ns string, addrs ...string) *p2p.RendezvousResponse {
+		req := p2p.NewRendezvousRequest(p2p.RendezvousRequest_REGISTER, ns)
+		req.Header = &p2p.Header{NodeId: author.String()}
+		for _, a := range addrs {
+			req.Addrs = append(req.Addrs, ma.StringCast(a).Bytes())
+		}
+		return r.handleRequest(author, req)
+	}
+
+	assert.False(t, register(self, "ns").Ok, "no addresses")
+	assert.True(t, register(self, "ns", "/ip4/10.0.0.1/tcp/1").Ok)
+
+	// Nodes can't register other nodes.
+	req := p2p.NewRendezvousRequest(p2p.RendezvousRequest_REGISTER, "ns")
+	req.Header = &p2p.Header{NodeId: self.String()}
+	assert.False(t, r.handleRequest(other, req).Ok)
+
+	discover := p2p.NewRendezvousRequest(p2p.RendezvousRequest_DISCOVER, "ns")
+	discover.Header = &p2p.Header{NodeId: other.String()}
+	resp := r.handleRequest(other, discover)
+	require.True(t, resp.Ok)
+	require.Len(t, resp.Registrations, 1)
+	assert.Equal(t, self.String(), resp.Registrations[0].PeerId)
+
+	// The requesting peer doesn't find itself.
+	discover.Header = &p2p.Header{NodeId: self.String()}
+	assert.Empty(t, r.handleRequest(self, discover).Registrations)
+
+	unregister := p2p.NewRendezvousRequest(p2p.RendezvousRequest_UNREGISTER, "ns")
+	unregister.Header = &p2p.Header{NodeId: self.String()}
+	assert.True(t, r.handleRequest(self, unregister).Ok)
+
+	discover.Header = &p2p.Header{NodeId: other.String()}
+	assert.Empty(t, r.handleRequest(other, discover).Registrations)
+}
+
+func TestRendezvousServer_limits(t *testing.T) {
+	defer func(namespaces, registrations int) {
+		rendezvousMaxNamespaces, rendezvousMaxRegistrations = namespaces, registrations
+	}(rendezvousMaxNamespaces, rendezvousMaxRegistrations)
+	rendezvousMaxNamespaces, rendezvousMaxRegistrations = 1, 1
+
+	n := mockNode(t)
+	r := NewRendezvousServer(n)
+	defer r.Close()
+
+	self := n.ID()
+	other, err := peer.Decode(testPeerID)
+	require.NoError(t, err)
+
+	register := func(author peer.ID, ns string) error {
+		req := p2p.NewRendezvousRequest(p2p.RendezvousRequest_REGISTER, ns)
+		req.Addrs = [][]byte{ma.StringCast("/ip4/10.0.0.1/tcp/1").Bytes()}
+		return r.register(author, req)
+	}
+
+	require.NoError(t, register(self, "ns"))
+	assert.NoError(t, register(self, "ns"), "renewal")
+	assert.Error(t, register(other, "ns"))
+	assert.Error(t, register(other, "other"))
+
+	// Expired registrations make room again.
+	r.registrations["ns"][self].expires = time.Now().Add(-time.Second)
+	assert.NoError(t, register(other, "other"))
+}
+
+func TestRendezvousServer_sweep(t *testing.T) {
+	n := mockNode(t)
+	r := NewRendezvousServer(n)
+	defer r.Close()
+
+	req := p2p.NewRendezvousRequest(p2p.RendezvousRequest_REGISTER, "ns")
+	req.Addrs = [][]byte{ma.StringCast("/ip4/10.0.0.1/tcp/1").Bytes()}
+	require.NoError(t, r.register(n.ID(), req))
+
+	r.registrations["ns"][n.ID()].expires = time.Now().Add(-time.Second)
+	r.sweep()
+	assert.Empty(t, r.registrations)
+}
@@ -0,0 +1,153 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// RotateKey signs the statement that the old key was replaced
+// with the new one.
+func RotateKey(oldKey crypto.PrivKey, newKey crypto.PrivKey) (*p2p.IdentityRotation, error) {
+	oldID, err := peer.IDFromPrivateKey(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	newID, err := peer.IDFromPrivateKey(newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := crypto.MarshalPublicKey(oldKey.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &p2p.IdentityRotation{
+		OldPeerId: oldID.String(),
+		OldPubKey: pubKey,
+		NewPeerId: newID.String(),
+		IssuedAt:  appTime.Now().Unix(),
+	}
+
+	if r.Signature, err = signStatement(oldKey, domainRotation, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// verifyRotation checks that the given rotation is signed by the old key.
+func verifyRotation(r *p2p.IdentityRotation) error {
+	unsigned := proto.Clone(r).(*p2p.IdentityRotation)
+	unsigned.Signature = nil
+	return verifyStatement(r.OldPeerId, r.OldPubKey, domainRotation, unsigned, r.Signature)
+}
+
+// EncodeRotation converts the given rotation into a string, so it
+// can be stored with the identity.
+func EncodeRotation(r *p2p.IdentityRotation) (string, error) {
+	text, err := encodeText(r)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(text)), nil
+}
+
+// DecodeRotation parses a rotation that EncodeRotation encoded.
+func DecodeRotation(s string) (*p2p.IdentityRotation, error) {
+	r := &p2p.IdentityRotation{}
+	if err := decodeText([]byte(s), r); err != nil {
+		return nil, fmt.Errorf("invalid identity rotation: %w", err)
+	}
+	return r, nil
+}
+
+// ReplacedKeys returns the peer IDs that the given peer used before,
+// newest first, according to the given chain of rotations. The chain
+// is followed backwards from the peer as long as the rotations are
+// valid.
+func ReplacedKeys(peerID peer.ID, rotations []*p2p.IdentityRotation) []peer.ID {
+	var replaced []peer.ID
+	current := peerID.String()
+	for i := len(rotations) - 1; i >= 0; i-- {
+		r := rotations[i]
+		if r.NewPeerId != current {
+			break
+		}
+
+		oldID, err := peer.Decode(r.OldPeerId)
+		if err != nil {
+			break
+		}
+
+		if err = verifyRotation(r); err != nil {
+			log.Infof("Invalid identity rotation of peer %s: %s\n", peerID, err)
+			break
+		}
+
+		replaced = append(replaced, oldID)
+		current = r.OldPeerId
+	}
+	return replaced
+}
+
+// applyRotations moves the trust in the keys the given peer replaced
+// over to the peer. Contacts with an old key are updated and the old
+// keys are blocked, because they may be compromised.
+func (n *Node) applyRotations(peerID peer.ID, rotations []*p2p.IdentityRotation) {
+	replaced := ReplacedKeys(peerID, rotations)
+	if len(replaced) == 0 {
+		return
+	}
+
+	n.rotationLk.Lock()
+	defer n.rotationLk.Unlock()
+
+	for _, oldID := range replaced {
+		// The rotation was already applied.
+		if n.IsBlocked(oldID) {
+			continue
+		}
+
+		log.Infof("Peer %s replaced its old identity %s\n", peerID, oldID)
+		n.Block(oldID, time.Time{})
+		if n.blocklist != nil && n.blocklist.Add(oldID.String()) {
+			if err := n.blocklist.Save(); err != nil {
+				log.Infoln(err)
+			}
+		}
+
+		if n.contacts == nil {
+			continue
+		}
+
+		contact, found := n.contacts.ByPeerID(oldID.String())
+		if !found {
+			continue
+		}
+
+		// The addresses may contain the old peer ID.
+		contact.PeerID = peerID.String()
+		for i, s := range contact.Addrs {
+			if addr, err := ma.NewMultiaddr(s); err == nil {
+				transport, _ := peer.SplitAddr(addr)
+				contact.Addrs[i] = transport.String()
+			}
+		}
+
+		if err := n.contacts.Save(); err != nil {
+			log.Infoln(err)
+			continue
+		}
+		log.Infof("Updated contact %s to the new identity\n", contact.Name)
+	}
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func rotationKeys(t *testing.T, n int) ([]crypto.PrivKey, []peer.ID) {
+	var keys []crypto.PrivKey
+	var ids []peer.ID
+	for i := 0; i < n; i++ {
+		key, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+		require.NoError(t, err)
+		id, err := peer.IDFromPrivateKey(key)
+		require.NoError(t, err)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	return keys, ids
+}
+
+// tempConfigHome lets the configuration files of a test end up in a
+// temporary directory.
+func tempConfigHome(t *testing.T) {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+}
+
+func TestReplacedKeys_followsChain(t *testing.T) {
+	keys, ids := rotationKeys(t, 3)
+
+	r1, err := RotateKey(keys[0], keys[1])
+	require.NoError(t, err)
+	r2, err := RotateKey(keys[1], keys[2])
+	require.NoError(t, err)
+
+	rotations := []*p2p.IdentityRotation{r1, r2}
+	assert.Equal(t, []peer.ID{ids[1], ids[0]}, ReplacedKeys(ids[2], rotations))
+	assert.Equal(t, []peer.ID{ids[0]}, ReplacedKeys(ids[1], rotations[:1]))
+	assert.Empty(t, ReplacedKeys(ids[1], rotations), "the chain doesn't end at the peer")
+}
+
+func TestReplacedKeys_stopsAtForgedRotation(t *testing.T) {
+	keys, ids := rotationKeys(t, 3)
+
+	// Someone claims to be the successor of the victim's key.
+	forged, err := RotateKey(keys[1], keys[2])
+	require.NoError(t, err)
+	forged.OldPeerId = ids[0].String()
+
+	assert.Empty(t, ReplacedKeys(ids[2], []*p2p.IdentityRotation{forged}))
+}
+
+func TestReplacedKeys_rejectsDeviceLink(t *testing.T) {
+	keys, ids := rotationKeys(t, 2)
+
+	// Device links have the same encoding as rotations, so a linked
+	// device could otherwise present its link as a rotation of the owner.
+	link, err := LinkDevice(keys[0], ids[1])
+	require.NoError(t, err)
+	data, err := proto.Marshal(link)
+	require.NoError(t, err)
+
+	r := &p2p.IdentityRotation{}
+	require.NoError(t, proto.Unmarshal(data, r))
+	require.Equal(t, ids[1].String(), r.NewPeerId)
+	assert.Empty(t, ReplacedKeys(ids[1], []*p2p.IdentityRotation{r}))
+}
+
+func TestEncodeRotation_roundTrip(t *testing.T) {
+	keys, ids := rotationKeys(t, 2)
+
+	r, err := RotateKey(keys[0], keys[1])
+	require.NoError(t, err)
+
+	s, err := EncodeRotation(r)
+	require.NoError(t, err)
+
+	decoded, err := DecodeRotation(s)
+	require.NoError(t, err)
+	assert.Equal(t, []peer.ID{ids[0]}, ReplacedKeys(ids[1], []*p2p.IdentityRotation{decoded}))
+}
+
+func TestInfoProtocol_RequestInfo_appliesRotations(t *testing.T) {
+	tempConfigHome(t)
+
+	n1, n2 := nodePair(t, nil)
+	keys, ids := rotationKeys(t, 1)
+
+	n1.gater, _ = NewConnectionGater(true, nil, nil)
+	n1.blocklist = &config.Blocklist{}
+	n1.contacts = &config.AddressBook{}
+	require.NoError(t, n1.contacts.Add(&config.Contact{
+		Name:     "alice",
+		PeerID:   ids[0].String(),
+		Addrs:    []string{"/ip4/10.0.3.7/tcp/44044/p2p/" + ids[0].String()},
+		Verified: true,
+	}))
+
+	r, err := RotateKey(keys[0], n2.Peerstore().PrivKey(n2.ID()))
+	require.NoError(t, err)
+	n2.rotations = []*p2p.IdentityRotation{r}
+
+	ctx := context.Background()
+	_, err = n1.RequestInfo(ctx, n2.ID())
+	require.NoError(t, err)
+
+	contact, found := n1.contacts.Lookup("alice")
+	require.True(t, found)
+	assert.Equal(t, n2.ID().String(), contact.PeerID)
+	assert.Equal(t, []string{"/ip4/10.0.3.7/tcp/44044"}, contact.Addrs)
+	assert.True(t, contact.Verified)
+
+	assert.True(t, n1.IsBlocked(ids[0]))
+	assert.True(t, n1.blocklist.Contains(ids[0].String()))
+}
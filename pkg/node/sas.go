@@ -0,0 +1,96 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// The formats in which short authentication strings are displayed.
+const (
+	SASDigits = "digits"
+	SASEmoji  = "emoji"
+)
+
+// The number of emoji in a short authentication string. Each
+// one encodes 6 bits.
+const sasEmojiCount = 5
+
+// The length of the nonce each peer contributes to a session in bytes.
+const sessionSize = 16
+
+// The emoji are easy to tell apart and to name, so users can
+// also compare them over the phone.
+var sasEmoji = [64]string{
+	"🐶", "🐱", "🦁", "🐎", "🦄", "🐷", "🐘", "🐰",
+	"🐼", "🐓", "🐧", "🐢", "🐟", "🐙", "🦋", "🌷",
+	"🌳", "🌵", "🍄", "🌏", "🌙", "☁️", "🔥", "🍌",
+	"🍎", "🍓", "🌽", "🍕", "🎂", "❤️", "😀", "🤖",
+	"🎩", "👓", "🔧", "🎅", "👍", "☂️", "⌛", "⏰",
+	"🎁", "💡", "📕", "✏️", "📎", "✂️", "🔒", "🔑",
+	"🔨", "☎️", "🏁", "🚂", "🚲", "✈️", "🚀", "🏆",
+	"⚽", "🎸", "🎺", "🔔", "⚓", "🎧", "📁", "📌",
+}
+
+// ValidateSASFormat returns an error if the given format is neither
+// empty, digits nor emoji.
+func ValidateSASFormat(format string) error {
+	switch format {
+	case "", SASDigits, SASEmoji:
+		return nil
+	default:
+		return fmt.Errorf("unknown verification format %q, expected %s or %s", format, SASDigits, SASEmoji)
+	}
+}
+
+// ShortAuthString derives a short authentication string from the
+// public keys of two peers and a session. Both peers get the same
+// string, so users can compare it on their screens. A man in the
+// middle would have to present its own keys, which changes it.
+func ShortAuthString(a crypto.PubKey, b crypto.PubKey, session []byte, format string) (string, error) {
+	if err := ValidateSASFormat(format); err != nil {
+		return "", err
+	}
+
+	ka, err := crypto.MarshalPublicKey(a)
+	if err != nil {
+		return "", err
+	}
+	kb, err := crypto.MarshalPublicKey(b)
+	if err != nil {
+		return "", err
+	}
+
+	// Sort the keys, so it doesn't matter which peer computes it.
+	if bytes.Compare(ka, kb) > 0 {
+		ka, kb = kb, ka
+	}
+	sum := sha256.Sum256(spakeTranscript([]byte("p2p SAS"), ka, kb, session))
+
+	if format == SASEmoji {
+		bits := binary.BigEndian.Uint64(sum[:8])
+		emoji := make([]string, sasEmojiCount)
+		for i := range emoji {
+			emoji[i] = sasEmoji[bits>>(64-6*(i+1))&63]
+		}
+		return strings.Join(emoji, " "), nil
+	}
+
+	digits := binary.BigEndian.Uint32(sum[:4]) % 1000000
+	return fmt.Sprintf("%03d %03d", digits/1000, digits%1000), nil
+}
+
+// ShortAuthString returns the short authentication string of the
+// session with the given connected peer in the configured format.
+func (n *Node) ShortAuthString(peerID peer.ID, session []byte) (string, error) {
+	remote := n.Peerstore().PubKey(peerID)
+	if remote == nil {
+		return "", fmt.Errorf("unknown public key of peer %s", peerID)
+	}
+	return ShortAuthString(n.Peerstore().PubKey(n.ID()), remote, session, n.sasFormat)
+}
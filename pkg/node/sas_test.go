@@ -0,0 +1,79 @@
+package node
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sasKeys(t *testing.T) (crypto.PubKey, crypto.PubKey) {
+	_, a, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	_, b, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	return a, b
+}
+
+func TestShortAuthString_isSymmetric(t *testing.T) {
+	a, b := sasKeys(t)
+	session := []byte("session")
+
+	for _, format := range []string{"", SASDigits, SASEmoji} {
+		sas1, err := ShortAuthString(a, b, session, format)
+		require.NoError(t, err)
+		sas2, err := ShortAuthString(b, a, session, format)
+		require.NoError(t, err)
+		assert.Equal(t, sas1, sas2)
+	}
+}
+
+func TestShortAuthString_dependsOnKeysAndSession(t *testing.T) {
+	a, b := sasKeys(t)
+	_, c := sasKeys(t)
+
+	sas, err := ShortAuthString(a, b, []byte("session"), SASDigits)
+	require.NoError(t, err)
+
+	other, err := ShortAuthString(a, c, []byte("session"), SASDigits)
+	require.NoError(t, err)
+	assert.NotEqual(t, sas, other)
+
+	other, err = ShortAuthString(a, b, []byte("other session"), SASDigits)
+	require.NoError(t, err)
+	assert.NotEqual(t, sas, other)
+}
+
+func TestShortAuthString_formats(t *testing.T) {
+	a, b := sasKeys(t)
+
+	sas, err := ShortAuthString(a, b, []byte("session"), SASDigits)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9]{3} [0-9]{3}$`, sas)
+
+	sas, err = ShortAuthString(a, b, []byte("session"), SASEmoji)
+	require.NoError(t, err)
+	emoji := strings.Split(sas, " ")
+	assert.Len(t, emoji, sasEmojiCount)
+	for _, e := range emoji {
+		assert.Contains(t, sasEmoji, e)
+	}
+
+	_, err = ShortAuthString(a, b, []byte("session"), "words")
+	assert.Error(t, err)
+}
+
+func TestNode_ShortAuthString_matchesOnBothPeers(t *testing.T) {
+	n1, n2 := mockNode(t), mockNode(t)
+	n1.Peerstore().AddPubKey(n2.ID(), n2.Peerstore().PubKey(n2.ID()))
+	n2.Peerstore().AddPubKey(n1.ID(), n1.Peerstore().PubKey(n1.ID()))
+
+	sas1, err := n1.ShortAuthString(n2.ID(), []byte("session"))
+	require.NoError(t, err)
+	sas2, err := n2.ShortAuthString(n1.ID(), []byte("session"))
+	require.NoError(t, err)
+	assert.Equal(t, sas1, sas2)
+}
@@ -0,0 +1,218 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// pattern: /protocol-name/request-or-response-message/version
+const ProtocolSession = "/p2p/session/0.1.0"
+
+// The time a negotiated session can be used for a push request.
+var sessionTTL = 5 * time.Minute
+
+// The number of sessions a node keeps at most, so peers can't
+// exhaust its memory.
+var sessionMaxCount = 1024
+
+// SessionProtocol negotiates the session that a short authentication
+// string is derived from. The sender commits to its nonce before it
+// learns the nonce of the receiver and reveals it only afterwards, so
+// neither of them, nor a man in the middle, can try many sessions to
+// find one that yields the same string for different keys.
+type SessionProtocol struct {
+	node *Node
+
+	lk       sync.Mutex // protects sessions
+	sessions map[peer.ID]*negotiation
+}
+
+// negotiation is the state of a session with a remote peer on the
+// receiving side.
+type negotiation struct {
+	commitment []byte
+	nonce      []byte
+
+	// The session, once the remote peer revealed its nonce.
+	session []byte
+	expires time.Time
+}
+
+// NewSessionProtocol creates a new SessionProtocol.
+func NewSessionProtocol(node *Node) *SessionProtocol {
+	p := &SessionProtocol{node: node, sessions: map[peer.ID]*negotiation{}}
+	node.SetStreamHandler(ProtocolSession, p.onSessionRequest)
+	return p
+}
+
+// NegotiateSession negotiates a new session with the given peer, which
+// it keeps until TakeSession is called with it.
+func (p *SessionProtocol) NegotiateSession(ctx context.Context, peerID peer.ID) ([]byte, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	commitment := sha256.Sum256(nonce)
+
+	resp, err := p.sendSessionRequest(ctx, peerID, &p2p.SessionRequest{Commitment: commitment[:]})
+	if err != nil {
+		return nil, err
+	} else if len(resp.Nonce) != sessionSize {
+		return nil, fmt.Errorf("invalid session nonce")
+	}
+
+	if _, err = p.sendSessionRequest(ctx, peerID, &p2p.SessionRequest{Nonce: nonce}); err != nil {
+		return nil, err
+	}
+	return sessionOf(nonce, resp.Nonce), nil
+}
+
+// TakeSession returns true if the given session was negotiated with the
+// given peer and is still valid. It can only be taken once.
+func (p *SessionProtocol) TakeSession(peerID peer.ID, session []byte) bool {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	neg, found := p.sessions[peerID]
+	if !found || neg.session == nil || time.Now().After(neg.expires) {
+		return false
+	}
+	if subtle.ConstantTimeCompare(neg.session, session) != 1 {
+		return false
+	}
+	delete(p.sessions, peerID)
+	return true
+}
+
+// onSessionRequest handles both steps of a negotiation of the
+// remote peer.
+func (p *SessionProtocol) onSessionRequest(s network.Stream) {
+	defer s.Close()
+
+	req := &p2p.SessionRequest{}
+	if err := p.node.Read(s, req); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	resp := p.handleRequest(s.Conn().RemotePeer(), req)
+	if err := p.node.Send(s, resp); err != nil {
+		log.Infoln(err)
+		return
+	}
+
+	if err := p.node.WaitForEOF(s); err != nil {
+		log.Infoln(err)
+	}
+}
+
+// handleRequest answers the given request of the given peer.
+func (p *SessionProtocol) handleRequest(remote peer.ID, req *p2p.SessionRequest) *p2p.SessionResponse {
+	if author, err := req.PeerID(); err != nil || author != remote {
+		return p2p.NewSessionResponse(fmt.Errorf("the request wasn't authored by the connected peer"))
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	now := time.Now()
+
+	// First step: answer the commitment with a nonce of our own.
+	if len(req.Commitment) > 0 {
+		if len(req.Commitment) != sha256.Size {
+			return p2p.NewSessionResponse(fmt.Errorf("invalid commitment"))
+		}
+
+		if _, found := p.sessions[remote]; !found && len(p.sessions) >= sessionMaxCount {
+			p.prune(now)
+			if len(p.sessions) >= sessionMaxCount {
+				return p2p.NewSessionResponse(fmt.Errorf("too many sessions"))
+			}
+		}
+
+		nonce, err := newNonce()
+		if err != nil {
+			return p2p.NewSessionResponse(err)
+		}
+		p.sessions[remote] = &negotiation{commitment: req.Commitment, nonce: nonce, expires: now.Add(sessionTTL)}
+
+		resp := p2p.NewSessionResponse(nil)
+		resp.Nonce = nonce
+		return resp
+	}
+
+	// Second step: check that the revealed nonce matches the commitment.
+	neg, found := p.sessions[remote]
+	if !found || neg.session != nil || now.After(neg.expires) {
+		return p2p.NewSessionResponse(fmt.Errorf("no negotiation in progress"))
+	}
+
+	commitment := sha256.Sum256(req.Nonce)
+	if len(req.Nonce) != sessionSize || subtle.ConstantTimeCompare(commitment[:], neg.commitment) != 1 {
+		delete(p.sessions, remote)
+		return p2p.NewSessionResponse(fmt.Errorf("the nonce doesn't match the commitment"))
+	}
+
+	neg.session = sessionOf(req.Nonce, neg.nonce)
+	return p2p.NewSessionResponse(nil)
+}
+
+// prune removes all expired sessions.
+func (p *SessionProtocol) prune(now time.Time) {
+	for peerID, neg := range p.sessions {
+		if now.After(neg.expires) {
+			delete(p.sessions, peerID)
+		}
+	}
+}
+
+// sendSessionRequest sends the given request to the given peer and
+// returns the response if it was successful.
+func (p *SessionProtocol) sendSessionRequest(ctx context.Context, peerID peer.ID, req *p2p.SessionRequest) (*p2p.SessionResponse, error) {
+	s, err := p.node.NewStream(withRelayedConns(ctx, "session"), peerID, ProtocolSession)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err = p.node.Send(s, req); err != nil {
+		return nil, err
+	}
+
+	resp := &p2p.SessionResponse{}
+	if err = p.node.Read(s, resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.Ok {
+		return nil, fmt.Errorf("session negotiation failed: %s", resp.Error)
+	}
+	return resp, nil
+}
+
+// newNonce returns the random bytes each side contributes to a session.
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, sessionSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// sessionOf derives a session from the nonces of the sender
+// and the receiver.
+func sessionOf(senderNonce []byte, receiverNonce []byte) []byte {
+	sum := sha256.Sum256(spakeTranscript([]byte("p2p session"), senderNonce, receiverNonce))
+	return sum[:]
+}
@@ -0,0 +1,88 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestSessionProtocol_NegotiateSession(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+
+	session, err := sender.NegotiateSession(context.Background(), receiver.ID())
+	require.NoError(t, err)
+
+	assert.False(t, receiver.TakeSession(receiver.ID(), session))
+	assert.False(t, receiver.TakeSession(sender.ID(), []byte("other session")))
+	assert.True(t, receiver.TakeSession(sender.ID(), session))
+
+	// Sessions can only be used once.
+	assert.False(t, receiver.TakeSession(sender.ID(), session))
+}
+
+func TestSessionProtocol_NegotiateSession_differs(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+
+	session1, err := sender.NegotiateSession(context.Background(), receiver.ID())
+	require.NoError(t, err)
+	session2, err := sender.NegotiateSession(context.Background(), receiver.ID())
+	require.NoError(t, err)
+
+	assert.NotEqual(t, session1, session2)
+	assert.False(t, receiver.TakeSession(sender.ID(), session1))
+	assert.True(t, receiver.TakeSession(sender.ID(), session2))
+}
+
+func TestSessionProtocol_TakeSession_notNegotiated(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+
+	nonce, err := newNonce()
+	require.NoError(t, err)
+	assert.False(t, receiver.TakeSession(sender.ID(), sessionOf(nonce, nonce)))
+}
+
+func TestSessionProtocol_NegotiateSession_wrongNonce(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+	ctx := context.Background()
+
+	nonce, err := newNonce()
+	require.NoError(t, err)
+	commitment := sha256.Sum256(nonce)
+
+	resp, err := sender.sendSessionRequest(ctx, receiver.ID(), &p2p.SessionRequest{Commitment: commitment[:]})
+	require.NoError(t, err)
+
+	// Reveal a nonce that was chosen after the one of the receiver.
+	other, err := newNonce()
+	require.NoError(t, err)
+	_, err = sender.sendSessionRequest(ctx, receiver.ID(), &p2p.SessionRequest{Nonce: other})
+	assert.Error(t, err)
+
+	assert.False(t, receiver.TakeSession(sender.ID(), sessionOf(other, resp.Nonce)))
+	assert.False(t, receiver.TakeSession(sender.ID(), sessionOf(nonce, resp.Nonce)))
+}
+
+func TestSessionProtocol_limits(t *testing.T) {
+	defer func(count int) { sessionMaxCount = count }(sessionMaxCount)
+	sessionMaxCount = 1
+
+	sender, receiver := nodePair(t, nil)
+	other := localNode(t, "other")
+	ctx := context.Background()
+
+	_, err := sender.NegotiateSession(ctx, receiver.ID())
+	require.NoError(t, err)
+
+	require.NoError(t, other.Connect(ctx, receiver.Peerstore().PeerInfo(receiver.ID())))
+	_, err = other.NegotiateSession(ctx, receiver.ID())
+	assert.Error(t, err)
+
+	// Peers can still renegotiate their own session.
+	_, err = sender.NegotiateSession(ctx, receiver.ID())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// The points M and N of the SPAKE2 exchange over edwards25519 as given
+// by RFC 9382. They are derived by hashing fixed seeds to the curve, so
+// nobody knows their discrete logarithm.
+var (
+	spakeM = mustDecodePoint("d048032c6ea0b6d697ddc2e86bda85a33adac920f1bf18e1b0c6d166a5cecdaf")
+	spakeN = mustDecodePoint("d3bfb518f44f3430f29d0c92af503865a1ed3281dc69b35dd868ba85f886c4ab")
+)
+
+// spake2 holds the state of one side of a SPAKE2 password-authenticated
+// key exchange (RFC 9382). Both sides derive the same keys only if they
+// used the same password, without revealing it to eavesdroppers or
+// allowing more than one guess per exchange.
+type spake2 struct {
+	initiator bool
+	w         *edwards25519.Scalar
+	secret    *edwards25519.Scalar
+
+	// The public element sent to the peer.
+	element []byte
+}
+
+// spakeKeys are the keys derived from a SPAKE2 exchange.
+type spakeKeys struct {
+	// The confirmations that prove the knowledge of the shared
+	// key. Each side sends its own and verifies the other one.
+	initiator []byte
+	responder []byte
+}
+
+// newSpake2 starts an exchange with the given password. The initiator
+// and the responder blind their elements with different points.
+func newSpake2(password []byte, initiator bool) (*spake2, error) {
+	sum := sha512.Sum512(password)
+	w, err := edwards25519.NewScalar().SetUniformBytes(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 64)
+	if _, err = rand.Read(random); err != nil {
+		return nil, err
+	}
+	secret, err := edwards25519.NewScalar().SetUniformBytes(random)
+	if err != nil {
+		return nil, err
+	}
+
+	// element = secret * G + w * blind
+	element := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(w, spakeBlind(initiator), secret)
+
+	return &spake2{
+		initiator: initiator,
+		w:         w,
+		secret:    secret,
+		element:   element.Bytes(),
+	}, nil
+}
+
+// finish derives the keys from the element of the peer. The IDs of the
+// initiator and the responder are bound to the keys, so they can't be
+// used in an exchange between other parties.
+func (s *spake2) finish(peerElement []byte, initiatorID []byte, responderID []byte) (*spakeKeys, error) {
+	peer, err := new(edwards25519.Point).SetBytes(peerElement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPAKE2 element")
+	}
+
+	// Remove the blinding of the peer: K = h * secret * (peer - w * blind),
+	// with the cofactor h, so small subgroup elements end up as identity.
+	blind := new(edwards25519.Point).ScalarMult(s.w, spakeBlind(!s.initiator))
+	k := new(edwards25519.Point).Subtract(peer, blind)
+	k.MultByCofactor(k)
+	k.ScalarMult(s.secret, k)
+	if k.Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, fmt.Errorf("invalid SPAKE2 element")
+	}
+
+	initElement, respElement := s.element, peerElement
+	if !s.initiator {
+		initElement, respElement = peerElement, s.element
+	}
+
+	transcript := spakeTranscript(
+		initiatorID,
+		responderID,
+		initElement,
+		respElement,
+		k.Bytes(),
+		s.w.Bytes(),
+	)
+	hash := sha256.Sum256(transcript)
+	ka := hash[16:]
+
+	return &spakeKeys{
+		initiator: spakeMAC(spakeMAC(ka, []byte("ConfirmationKeys initiator")), transcript),
+		responder: spakeMAC(spakeMAC(ka, []byte("ConfirmationKeys responder")), transcript),
+	}, nil
+}
+
+// spakeBlind returns the point that blinds the element of the initiator
+// or the responder respectively.
+func spakeBlind(initiator bool) *edwards25519.Point {
+	if initiator {
+		return spakeM
+	}
+	return spakeN
+}
+
+// spakeTranscript concatenates the given values, each prefixed
+// with its length.
+func spakeTranscript(values ...[]byte) []byte {
+	var transcript []byte
+	for _, v := range values {
+		transcript = binary.LittleEndian.AppendUint64(transcript, uint64(len(v)))
+		transcript = append(transcript, v...)
+	}
+	return transcript
+}
+
+func spakeMAC(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func mustDecodePoint(s string) *edwards25519.Point {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	p, err := new(edwards25519.Point).SetBytes(b)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
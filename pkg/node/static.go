@@ -0,0 +1,168 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// The time between two reachability probes of the static peers.
+var staticProbeInterval = 10 * time.Second
+
+// The time a single reachability probe may take.
+var staticProbeTimeout = 3 * time.Second
+
+// StaticDiscoverer lists a fixed set of peers, e.g. from the settings
+// file, and probes them regularly. Unlike discovered peers, unreachable
+// static peers stay in the list and are marked as offline.
+type StaticDiscoverer struct {
+	peerEvents
+	node  *Node
+	peers map[peer.ID]*PeerInfo
+
+	lk     sync.Mutex // protects cancel
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewStaticDiscoverer creates a StaticDiscoverer for the given
+// multiaddresses. Each of them must contain the /p2p/ component.
+func NewStaticDiscoverer(node *Node, addrs []string) (*StaticDiscoverer, error) {
+	s := &StaticDiscoverer{
+		node:  node,
+		peers: map[peer.ID]*PeerInfo{},
+	}
+
+	for _, str := range addrs {
+		addr, err := ma.NewMultiaddr(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid static peer %q: %w", str, err)
+		}
+
+		pi, err := peer.AddrInfoFromP2pAddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid static peer %q: %w", str, err)
+		}
+
+		if p, found := s.peers[pi.ID]; found {
+			p.pi.Addrs = append(p.pi.Addrs, pi.Addrs...)
+			continue
+		}
+
+		p := NewPeerInfo(*pi, SourceStatic)
+		// Peers are offline until the first probe succeeds.
+		p.SetOnline(false)
+		s.peers[pi.ID] = p
+	}
+
+	return s, nil
+}
+
+// Start probes all static peers immediately and then periodically
+// in the background.
+func (s *StaticDiscoverer) Start(ctx context.Context) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.cancel != nil {
+		return nil
+	}
+
+	for _, p := range s.peers {
+		s.emit(PeerEvent{Type: PeerJoined, Peer: p.pi})
+	}
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			s.probeAll(probeCtx)
+
+			t := appTime.NewTimer(staticProbeInterval)
+			select {
+			case <-probeCtx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops probing the static peers.
+func (s *StaticDiscoverer) Stop() error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+
+	return nil
+}
+
+// PeersList returns all static peers, reachable or not.
+func (s *StaticDiscoverer) PeersList() []peer.AddrInfo {
+	peers := []peer.AddrInfo{}
+	for _, p := range s.peers {
+		peers = append(peers, p.pi)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+
+	return peers
+}
+
+// PeerInfo returns the stored information about the given peer.
+func (s *StaticDiscoverer) PeerInfo(peerID peer.ID) (*PeerInfo, bool) {
+	p, found := s.peers[peerID]
+	return p, found
+}
+
+// probeAll checks the reachability of all static peers in parallel.
+func (s *StaticDiscoverer) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range s.peers {
+		wg.Add(1)
+		go func(p *PeerInfo) {
+			defer wg.Done()
+			s.probe(ctx, p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// probe connects to the given peer and records whether it's reachable.
+// The information the peer advertises is queried on first contact.
+func (s *StaticDiscoverer) probe(ctx context.Context, p *PeerInfo) {
+	ctx, cancel := context.WithTimeout(ctx, staticProbeTimeout)
+	defer cancel()
+
+	online := s.node.Connect(ctx, p.pi) == nil
+	updated := p.Online() != online
+	p.SetOnline(online)
+
+	if online && p.Info() == nil && s.node.InfoProtocol != nil {
+		updated = s.node.fillPeerInfo(p) || updated
+	}
+
+	if updated {
+		s.emit(PeerEvent{Type: PeerUpdated, Peer: p.pi})
+	}
+}
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStaticDiscoverer_rejectsAddressWithoutPeerID(t *testing.T) {
+	n := mockNode(t)
+	_, err := NewStaticDiscoverer(n, []string{"/ip4/10.0.3.7/tcp/44044"})
+	assert.Error(t, err)
+}
+
+func TestStaticDiscoverer_marksUnreachablePeersOffline(t *testing.T) {
+	net := mocknet.New()
+	h1, err := net.GenPeer()
+	require.NoError(t, err)
+	h2, err := net.GenPeer()
+	require.NoError(t, err)
+	h3, err := net.GenPeer()
+	require.NoError(t, err)
+
+	// h3 is not linked and thus unreachable.
+	_, err = net.LinkPeers(h1.ID(), h2.ID())
+	require.NoError(t, err)
+
+	n1 := &Node{Host: h1}
+	n1.InfoProtocol = NewInfoProtocol(n1, "node-1")
+	n2 := &Node{Host: h2}
+	n2.InfoProtocol = NewInfoProtocol(n2, "node-2")
+	n2.SetRole(RoleReceiver)
+
+	reachable, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	require.NoError(t, err)
+	unreachable, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: h3.ID(), Addrs: h3.Addrs()})
+	require.NoError(t, err)
+
+	s, err := NewStaticDiscoverer(n1, []string{reachable[0].String(), unreachable[0].String()})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop()
+
+	assert.Eventually(t, func() bool {
+		p, _ := s.PeerInfo(h2.ID())
+		return p.Online() && p.Info() != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	p, found := s.PeerInfo(h3.ID())
+	require.True(t, found)
+	assert.False(t, p.Online())
+	assert.Len(t, s.PeersList(), 2)
+
+	d := NewDiscovery(s)
+	assert.Contains(t, d.describePeer(h2.ID()), "node-2")
+	assert.Contains(t, d.describePeer(h2.ID()), "[static]")
+	assert.Contains(t, d.describePeer(h3.ID()), "[static, offline]")
+
+	// Offline peers can't be picked.
+	peers := d.PeersWithRole(RoleReceiver)
+	require.Len(t, peers, 1)
+	assert.Equal(t, h2.ID(), peers[0].ID)
+}
@@ -2,6 +2,8 @@ package node
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"sync"
 	"time"
@@ -17,9 +19,27 @@ import (
 
 // pattern: /protocol-name/request-or-response-message/version
 const (
-	ProtocolTransfer = "/p2p/transfer/0.1.0"
+	ProtocolTransfer = "/p2p/transfer/0.2.0"
+
+	// The previous version, which doesn't tell the sender whether
+	// the data was verified.
+	protocolTransferUnverified = "/p2p/transfer/0.1.0"
+)
+
+// The acknowledgments of a transfer, which tell whether the
+// data matched the content ID the sender announced.
+const (
+	transferUnverified byte = 0
+	transferVerified   byte = 1
 )
 
+// The time we wait for the acknowledgment of a transfer.
+var transferAckTimeout = 10 * time.Second
+
+// ErrRelayLimit is returned if a relayed transfer breaks off. That
+// usually means it exceeded the limits of the relay.
+var ErrRelayLimit = errors.New("the relayed transfer broke off, it probably exceeds the limits of the relay (p2p relay --max-bytes and --max-duration)")
+
 // TransferProtocol encapsulates data necessary to fulfill its protocol.
 type TransferProtocol struct {
 	node *Node
@@ -27,8 +47,11 @@ type TransferProtocol struct {
 	th   TransferHandler
 }
 
+// TransferHandler receives the data of a transfer. The sender only
+// gets an acknowledgment if HandleTransfer returns no error. It tells
+// whether the handler verified the data against its content ID.
 type TransferHandler interface {
-	HandleTransfer(r io.Reader)
+	HandleTransfer(r io.Reader) (bool, error)
 	GetLimit() int64
 	GetPeerID() peer.ID
 }
@@ -44,55 +67,178 @@ func (t *TransferProtocol) RegisterTransferHandler(th TransferHandler) {
 	defer t.lk.Unlock()
 	t.th = th
 	t.node.SetStreamHandler(ProtocolTransfer, t.onTransfer)
+	t.node.SetStreamHandler(protocolTransferUnverified, t.onTransfer)
 }
 
 func (t *TransferProtocol) UnregisterTransferHandler() {
 	t.lk.Lock()
 	defer t.lk.Unlock()
 	t.node.RemoveStreamHandler(ProtocolTransfer)
+	t.node.RemoveStreamHandler(protocolTransferUnverified)
 	t.th = nil
 }
 
 // onTransfer is called when the peer initiates a file transfer.
 func (t *TransferProtocol) onTransfer(s network.Stream) {
 	t.lk.RLock()
-	defer func() {
-		if err := s.Close(); err != nil {
+	defer t.lk.RUnlock()
+
+	remote := s.Conn().RemotePeer()
+	if t.th == nil || t.th.GetPeerID() != remote {
+		log.Infoln("Received data transfer attempt from unexpected peer")
+		if err := s.Reset(); err != nil {
 			log.Infoln(err)
 		}
-		t.lk.RUnlock()
-	}()
-
-	if t.th.GetPeerID() != s.Conn().RemotePeer() {
-		log.Infof("Transfer initiated from unexpected peer %q instead of %q\n", s.Conn().RemotePeer(), t.th.GetPeerID())
 		return
 	}
 
 	// Only read as much as we expect to avoid stuffing.
 	lr := io.LimitReader(s, t.th.GetLimit())
 
-	t.th.HandleTransfer(lr)
+	verified, err := t.th.HandleTransfer(lr)
+	if err != nil {
+		// Resetting the stream tells the sender that the transfer failed.
+		if err := s.Reset(); err != nil {
+			log.Infoln(err)
+		}
+		return
+	}
+
+	if s.Protocol() == ProtocolTransfer {
+		ack := transferUnverified
+		if verified {
+			ack = transferVerified
+		}
+		if _, err := s.Write([]byte{ack}); err != nil {
+			log.Infoln(err)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		log.Infoln(err)
+	}
 }
 
 // Transfer can be called to transfer the given payload to the given peer. The PushRequest is used for displaying
 // the progress to the user. This function returns when the bytes where transmitted and we have received an
-// acknowledgment.
-func (t *TransferProtocol) Transfer(ctx context.Context, peerID peer.ID, payload io.Reader) (int64, error) {
+// acknowledgment, which tells whether the peer verified the data.
+func (t *TransferProtocol) Transfer(ctx context.Context, peerID peer.ID, payload io.Reader) (int64, bool, error) {
 
 	// Open a new stream to our peer.
-	s, err := t.node.NewStream(ctx, peerID, ProtocolTransfer)
+	s, err := t.node.NewStream(withRelayedConns(ctx, "transfer"), peerID, ProtocolTransfer, protocolTransferUnverified)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 	defer s.Close()
 
 	// The actual file transfer.
 	written, err := io.Copy(s, payload)
 	if err != nil {
-		return 0, err
+		return 0, false, relayLimitError(s, err)
+	}
+
+	// Signal the end of the data, so relays can finish forwarding
+	// it before the peer acknowledges the transfer.
+	if err = s.CloseWrite(); err != nil {
+		return written, false, relayLimitError(s, err)
+	}
+
+	verified, err := t.waitForAck(s)
+	return written, verified, relayLimitError(s, err)
+}
+
+// waitForAck waits for the acknowledgment of a transfer over the given
+// stream and returns whether the peer verified the data. Peers with
+// the previous protocol version only close the stream.
+func (t *TransferProtocol) waitForAck(s network.Stream) (bool, error) {
+	if s.Protocol() != ProtocolTransfer {
+		return false, t.node.WaitForEOF(s)
 	}
 
-	return written, t.node.WaitForEOF(s)
+	if err := s.SetReadDeadline(time.Now().Add(transferAckTimeout)); err != nil {
+		return false, err
+	}
+	ack, err := io.ReadAll(io.LimitReader(s, 2))
+	if err != nil {
+		return false, err
+	} else if len(ack) != 1 {
+		return false, fmt.Errorf("invalid transfer acknowledgment")
+	}
+	return ack[0] == transferVerified, nil
+}
+
+// relayLimitError wraps the given error of a transfer over the given
+// stream in ErrRelayLimit if the stream is relayed.
+func relayLimitError(s network.Stream, err error) error {
+	if err == nil || !isRelayAddr(s.Conn().RemoteMultiaddr()) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrRelayLimit, err)
+}
+
+// TransferAll transfers the given payload to all given peers at once.
+// The payload is read only once, so the slowest peer sets the pace.
+// It returns whether each peer verified the data and the error of the
+// transfer to it, which is nil if the peer acknowledged it.
+func (t *TransferProtocol) TransferAll(ctx context.Context, peerIDs []peer.ID, payload io.Reader) ([]bool, []error) {
+	verified := make([]bool, len(peerIDs))
+	errs := make([]error, len(peerIDs))
+	writers := make([]*io.PipeWriter, len(peerIDs))
+
+	var wg sync.WaitGroup
+	for i, peerID := range peerIDs {
+		pr, pw := io.Pipe()
+		writers[i] = pw
+
+		wg.Add(1)
+		go func(i int, peerID peer.ID) {
+			defer wg.Done()
+			_, verified[i], errs[i] = t.Transfer(ctx, peerID, pr)
+
+			// Unblock the fan-out if the transfer failed early.
+			pr.CloseWithError(errTransferEnded)
+		}(i, peerID)
+	}
+
+	fanOut(payload, writers)
+	wg.Wait()
+	return verified, errs
+}
+
+// errTransferEnded is returned when writing data for a transfer that
+// ended already.
+var errTransferEnded = errors.New("transfer ended")
+
+// fanOut copies the data of the given reader to all given writers and
+// closes them afterwards. Writers that fail are skipped from then on.
+func fanOut(src io.Reader, dsts []*io.PipeWriter) {
+	active := make([]bool, len(dsts))
+	for i := range active {
+		active[i] = true
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		for i, dst := range dsts {
+			if active[i] && n > 0 {
+				if _, werr := dst.Write(buf[:n]); werr != nil {
+					active[i] = false
+				}
+			}
+		}
+
+		if err == io.EOF {
+			err = nil
+		} else if err == nil {
+			continue
+		}
+
+		for _, dst := range dsts {
+			dst.CloseWithError(err)
+		}
+		return
+	}
 }
 
 func IndicateProgress(ctx context.Context, bCounter progress.Counter, filename string, size int64, wg *sync.WaitGroup) {
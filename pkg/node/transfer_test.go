@@ -2,12 +2,16 @@ package node
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ansuman12chat/p2p/internal/log"
 	"github.com/ansuman12chat/p2p/internal/mock"
@@ -33,3 +37,69 @@ func TestTransferProtocol_onTransfer_unexpected(t *testing.T) {
 
 	assert.Equal(t, logOut, "Received data transfer attempt from unexpected peer")
 }
+
+func TestTransferProtocol_TransferAll(t *testing.T) {
+	sender := localNode(t, "sender")
+	receivers := []*Node{localNode(t, "receiver-1"), localNode(t, "receiver-2"), localNode(t, "receiver-3")}
+
+	payload := bytes.Repeat([]byte("p2p"), 100_000)
+	var handlers []*bufferTransferHandler
+	var peerIDs []peer.ID
+	for _, r := range receivers {
+		require.NoError(t, sender.Connect(context.Background(), peer.AddrInfo{ID: r.ID(), Addrs: r.Addrs()}))
+
+		th := &bufferTransferHandler{peerID: sender.ID(), limit: int64(len(payload)), done: make(chan struct{})}
+		r.RegisterTransferHandler(th)
+		handlers = append(handlers, th)
+		peerIDs = append(peerIDs, r.ID())
+	}
+	handlers[0].verified = true
+	handlers[1].fail = errors.New("content ID mismatch")
+
+	verified, errs := sender.TransferAll(context.Background(), peerIDs, bytes.NewReader(payload))
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Equal(t, []bool{true, false, false}, verified)
+
+	for _, th := range handlers {
+		<-th.done
+		assert.Equal(t, payload, th.buf.Bytes())
+	}
+}
+
+func TestTransferProtocol_TransferAll_unreachablePeer(t *testing.T) {
+	sender := localNode(t, "sender")
+	receiver := localNode(t, "receiver")
+	require.NoError(t, sender.Connect(context.Background(), peer.AddrInfo{ID: receiver.ID(), Addrs: receiver.Addrs()}))
+
+	payload := bytes.Repeat([]byte("p2p"), 100_000)
+	th := &bufferTransferHandler{peerID: sender.ID(), limit: int64(len(payload)), done: make(chan struct{})}
+	receiver.RegisterTransferHandler(th)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, errs := sender.TransferAll(ctx, []peer.ID{"unknown", receiver.ID()}, bytes.NewReader(payload))
+	assert.Error(t, errs[0])
+	assert.NoError(t, errs[1])
+
+	<-th.done
+	assert.Equal(t, payload, th.buf.Bytes())
+}
+
+func TestTransferProtocol_Transfer_previousVersion(t *testing.T) {
+	sender, receiver := nodePair(t, nil)
+
+	payload := bytes.Repeat([]byte("p2p"), 1000)
+	th := &bufferTransferHandler{peerID: sender.ID(), limit: int64(len(payload)), done: make(chan struct{}), verified: true}
+	receiver.RegisterTransferHandler(th)
+
+	// Receivers of the previous version can't tell whether they verified the data.
+	receiver.RemoveStreamHandler(ProtocolTransfer)
+
+	n, verified, err := sender.Transfer(context.Background(), receiver.ID(), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.EqualValues(t, len(payload), n)
+	assert.False(t, verified)
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// The transports a node can prefer when connecting to peers.
+const (
+	TransportTCP  = "tcp"
+	TransportQUIC = "quic"
+)
+
+// ValidateTransport returns an error if the given transport preference
+// is unknown. An empty preference is valid and leaves the choice to libp2p.
+func ValidateTransport(transport string) error {
+	switch transport {
+	case "", TransportTCP, TransportQUIC:
+		return nil
+	default:
+		return fmt.Errorf("unknown transport %q, expected %s or %s", transport, TransportTCP, TransportQUIC)
+	}
+}
+
+// transportDialRanker only dials the addresses of the given transport
+// if the peer has any. Relayed addresses are kept as a fallback. Peers
+// that don't offer the transport are dialed as usual.
+func transportDialRanker(transport string) network.DialRanker {
+	return func(addrs []ma.Multiaddr) []network.AddrDelay {
+		preferred := make([]ma.Multiaddr, 0, len(addrs))
+		direct := false
+		for _, addr := range addrs {
+			if isRelayAddr(addr) {
+				preferred = append(preferred, addr)
+			} else if addrTransport(addr) == transport {
+				preferred = append(preferred, addr)
+				direct = true
+			}
+		}
+
+		if !direct {
+			return swarm.DefaultDialRanker(addrs)
+		}
+		return swarm.DefaultDialRanker(preferred)
+	}
+}
+
+// addrTransport returns the transport of the given address.
+func addrTransport(addr ma.Multiaddr) string {
+	if _, err := addr.ValueForProtocol(ma.P_QUIC_V1); err == nil {
+		return TransportQUIC
+	}
+	if _, err := addr.ValueForProtocol(ma.P_QUIC); err == nil {
+		return TransportQUIC
+	}
+	if _, err := addr.ValueForProtocol(ma.P_TCP); err == nil {
+		return TransportTCP
+	}
+	return ""
+}
+
+// ConnTransport returns the transport of the connection to the given
+// peer, e.g. to show which one is used for a transfer.
+func (n *Node) ConnTransport(peerID peer.ID) string {
+	for _, conn := range n.Network().ConnsToPeer(peerID) {
+		if isRelayAddr(conn.RemoteMultiaddr()) {
+			continue
+		}
+		return addrTransport(conn.RemoteMultiaddr())
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func rankedAddrs(transport string, addrs ...string) []string {
+	maddrs := make([]ma.Multiaddr, 0, len(addrs))
+	for _, a := range addrs {
+		maddrs = append(maddrs, ma.StringCast(a))
+	}
+
+	ranked := []string{}
+	for _, ad := range transportDialRanker(transport)(maddrs) {
+		ranked = append(ranked, ad.Addr.String())
+	}
+	return ranked
+}
+
+func TestValidateTransport(t *testing.T) {
+	assert.NoError(t, ValidateTransport(""))
+	assert.NoError(t, ValidateTransport(TransportTCP))
+	assert.NoError(t, ValidateTransport(TransportQUIC))
+	assert.Error(t, ValidateTransport("udp"))
+}
+
+func TestTransportDialRanker_onlyDialsPreferredTransport(t *testing.T) {
+	tcp := "/ip4/10.0.3.7/tcp/44044"
+	quic := "/ip4/10.0.3.7/udp/44044/quic-v1"
+	relayed := "/ip4/10.0.3.1/tcp/44047/p2p/" + testPeerID + "/p2p-circuit"
+
+	assert.ElementsMatch(t, []string{tcp, relayed}, rankedAddrs(TransportTCP, tcp, quic, relayed))
+	assert.ElementsMatch(t, []string{quic, relayed}, rankedAddrs(TransportQUIC, tcp, quic, relayed))
+
+	// Peers that don't offer the preferred transport are dialed as usual.
+	assert.ElementsMatch(t, []string{tcp, relayed}, rankedAddrs(TransportQUIC, tcp, relayed))
+}
@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// The time to wait for the information of a peer, e.g. its
+// nickname, certificate or device link.
+var peerInfoTimeout = 3 * time.Second
+
+// Trust tells why a peer is trusted, if at all.
+type Trust struct {
+	// Whether the peer belongs to the same user.
+	Linked bool
+
+	// The entry of the peer in the address book, if any.
+	Contact *config.Contact
+
+	// The valid certificate of a trusted authority that the peer
+	// holds, if any, or why it doesn't hold one otherwise.
+	Certificate    *p2p.Certificate
+	CertificateErr error
+}
+
+// IsTrusted returns true if the peer is a linked device, a contact
+// or holds a valid certificate.
+func (t *Trust) IsTrusted() bool {
+	return t.Linked || t.Contact != nil || t.Certificate != nil
+}
+
+// IsVerified returns true if the peer is a verified contact or holds
+// a valid certificate.
+func (t *Trust) IsVerified() bool {
+	return (t.Contact != nil && t.Contact.Verified) || t.Certificate != nil
+}
+
+// PeerTrust checks why the given peer is trusted. Peers that aren't
+// contacts are asked for their information, which also moves contacts
+// over to rotated keys, before they are looked up in the address book
+// again.
+func (n *Node) PeerTrust(peerID peer.ID) *Trust {
+	ctx, cancel := context.WithTimeout(context.Background(), peerInfoTimeout)
+	defer cancel()
+
+	t := &Trust{Contact: n.contact(peerID)}
+	if t.Contact == nil {
+		if _, err := n.presentedInfo(ctx, peerID); err == nil {
+			t.Contact = n.contact(peerID)
+		}
+	}
+
+	var err error
+	if t.Linked, err = n.IsLinkedDevice(ctx, peerID); err != nil {
+		log.Infof("Could not check if peer %s is a linked device: %s\n", peerID, err)
+	}
+
+	t.Certificate, t.CertificateErr = n.PeerCertificate(ctx, peerID)
+	return t
+}
+
+// PresentedTrust is like PeerTrust, but only checks the information the
+// given peer presented, so it never contacts the peer.
+func (n *Node) PresentedTrust(peerID peer.ID, info *p2p.InfoResponse) *Trust {
+	t := &Trust{Contact: n.contact(peerID)}
+
+	var err error
+	if t.Linked, err = n.PresentsLinkedDevice(peerID, info); err != nil {
+		log.Infof("Could not check if peer %s is a linked device: %s\n", peerID, err)
+	}
+
+	t.Certificate, t.CertificateErr = n.PresentedCertificate(peerID, info)
+	return t
+}
+
+// PresentedNickname returns the nickname of the given peer or its peer
+// ID if it doesn't present one.
+func (n *Node) PresentedNickname(peerID peer.ID) string {
+	ctx, cancel := context.WithTimeout(context.Background(), peerInfoTimeout)
+	defer cancel()
+
+	info, err := n.presentedInfo(ctx, peerID)
+	if err != nil || info.Nickname == "" {
+		return peerID.String()
+	}
+	return info.Nickname
+}
+
+// contact returns the entry of the given peer in the address book
+// or nil if there's none.
+func (n *Node) contact(peerID peer.ID) *config.Contact {
+	n.rotationLk.Lock()
+	defer n.rotationLk.Unlock()
+
+	if n.contacts == nil {
+		return nil
+	}
+	contact, _ := n.contacts.ByPeerID(peerID.String())
+	return contact
+}
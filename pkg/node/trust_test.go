@@ -0,0 +1,63 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func TestNode_PeerTrust(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+
+	trust := n1.PeerTrust(n2.ID())
+	assert.False(t, trust.IsTrusted())
+	assert.False(t, trust.IsVerified())
+
+	n1.contacts = &config.AddressBook{}
+	require.NoError(t, n1.contacts.Add(&config.Contact{Name: "bob", PeerID: n2.ID().String()}))
+
+	trust = n1.PeerTrust(n2.ID())
+	assert.True(t, trust.IsTrusted())
+	assert.False(t, trust.IsVerified())
+
+	trust.Contact.Verified = true
+	assert.True(t, n1.PeerTrust(n2.ID()).IsVerified())
+}
+
+func TestNode_PeerTrust_appliesRotationsFirst(t *testing.T) {
+	tempConfigHome(t)
+
+	n1, n2 := nodePair(t, nil)
+	keys, ids := rotationKeys(t, 1)
+
+	n1.gater, _ = NewConnectionGater(true, nil, nil)
+	n1.blocklist = &config.Blocklist{}
+	n1.contacts = &config.AddressBook{}
+	require.NoError(t, n1.contacts.Add(&config.Contact{Name: "alice", PeerID: ids[0].String(), Verified: true}))
+
+	r, err := RotateKey(keys[0], n2.Peerstore().PrivKey(n2.ID()))
+	require.NoError(t, err)
+	n2.rotations = []*p2p.IdentityRotation{r}
+
+	trust := n1.PeerTrust(n2.ID())
+	require.NotNil(t, trust.Contact)
+	assert.Equal(t, "alice", trust.Contact.Name)
+	assert.True(t, trust.IsVerified())
+}
+
+func TestNode_PresentedTrust(t *testing.T) {
+	n1, n2 := nodePair(t, nil)
+	n1.ownsDevices = true
+
+	info := n2.LocalInfo()
+	assert.False(t, n1.PresentedTrust(n2.ID(), info).IsTrusted())
+
+	var err error
+	info.DeviceLink, err = LinkDevice(n1.Peerstore().PrivKey(n1.ID()), n2.ID())
+	require.NoError(t, err)
+	assert.True(t, n1.PresentedTrust(n2.ID(), info).Linked)
+}
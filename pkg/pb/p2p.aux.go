@@ -40,3 +40,159 @@ func NewPushRequest(filename string, size int64, c cid.Cid) *PushRequest {
 		Cid:      c.Bytes(),
 	}
 }
+
+func (x *PullRequest) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *PullResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *PullRequest) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func (x *PullResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewPullRequest(c cid.Cid) *PullRequest {
+	return &PullRequest{Cid: c.Bytes()}
+}
+
+func NewPullResponse(err error) *PullResponse {
+	if err != nil {
+		return &PullResponse{Ok: false, Error: err.Error()}
+	}
+	return &PullResponse{Ok: true}
+}
+
+func (x *ListRequest) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *ListResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *ListRequest) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func (x *ListResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewListRequest(path string, offset int64, limit int64) *ListRequest {
+	return &ListRequest{Path: path, Offset: offset, Limit: limit}
+}
+
+func NewListResponse(err error) *ListResponse {
+	if err != nil {
+		return &ListResponse{Ok: false, Error: err.Error()}
+	}
+	return &ListResponse{Ok: true}
+}
+
+func (x *InfoResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *InfoResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewInfoResponse(nickname string, os string, version string, role string) *InfoResponse {
+	return &InfoResponse{
+		Nickname: nickname,
+		Os:       os,
+		Version:  version,
+		Role:     role,
+	}
+}
+
+func (x *RendezvousRequest) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *RendezvousResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *RendezvousRequest) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func (x *RendezvousResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewRendezvousRequest(typ RendezvousRequest_Type, namespace string) *RendezvousRequest {
+	return &RendezvousRequest{Type: typ, Namespace: namespace}
+}
+
+func NewRendezvousResponse(err error) *RendezvousResponse {
+	if err != nil {
+		return &RendezvousResponse{Ok: false, Error: err.Error()}
+	}
+	return &RendezvousResponse{Ok: true}
+}
+
+func (x *BroadcastBeacon) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *BroadcastBeacon) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewBroadcastBeacon(service string) *BroadcastBeacon {
+	return &BroadcastBeacon{Service: service}
+}
+
+func (x *PairingRequest) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *PairingResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *PairingRequest) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func (x *PairingResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewPairingResponse(err error) *PairingResponse {
+	if err != nil {
+		return &PairingResponse{Ok: false, Error: err.Error()}
+	}
+	return &PairingResponse{Ok: true}
+}
+
+func (x *SessionRequest) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *SessionResponse) SetHeader(hdr *Header) {
+	x.Header = hdr
+}
+
+func (x *SessionRequest) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func (x *SessionResponse) PeerID() (peer.ID, error) {
+	return peer.Decode(x.GetHeader().NodeId)
+}
+
+func NewSessionResponse(err error) *SessionResponse {
+	if err != nil {
+		return &SessionResponse{Ok: false, Error: err.Error()}
+	}
+	return &SessionResponse{Ok: true}
+}
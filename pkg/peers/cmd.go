@@ -0,0 +1,199 @@
+package peers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:    "peers",
+	Usage:   "Lists the peers in your local network.",
+	Aliases: []string{"p"},
+	Action:  Action,
+	Flags: append([]cli.Flag{
+		&cli.DurationFlag{
+			Name:    "wait",
+			Aliases: []string{"w"},
+			Usage:   "The time to wait for peers to announce themselves.",
+			Value:   3 * time.Second,
+		},
+		&cli.BoolFlag{
+			Name:    "all",
+			Aliases: []string{"a"},
+			Usage:   "List senders and other nodes too, not only peers that are ready to receive files.",
+		},
+		commons.SwarmKeyFlag,
+	}, commons.DiscoveryFlags...),
+	Subcommands: []*cli.Command{
+		{
+			Name:      "block",
+			Usage:     "Blocks all connections from and to a peer.",
+			ArgsUsage: "PEER_ID|CONTACT",
+			Action:    BlockAction,
+		},
+		{
+			Name:      "unblock",
+			Usage:     "Allows connections from and to a blocked peer again.",
+			ArgsUsage: "PEER_ID|CONTACT",
+			Action:    UnblockAction,
+		},
+		{
+			Name:   "blocked",
+			Usage:  "Lists all blocked peers.",
+			Action: BlockedAction,
+		},
+	},
+	Description: `The peers subcommand discovers the peers in your local network and prints the information they advertise.
+Blocked peers can neither connect to you nor be reached by you. Running nodes pick up changes to the
+blocklist on their next start.`,
+}
+
+// Action is the function that is called when running p2p peers.
+func Action(c *cli.Context) error {
+
+	ctx, err := config.FillContext(c.Context, c.String("profile"))
+	if err != nil {
+		return errors.Wrap(err, "failed loading configuration")
+	}
+
+	commons.ApplySwarmKeyFlag(ctx, c)
+
+	local, err := node.Init(ctx)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to init node"))
+	}
+	defer local.Close()
+
+	if server := c.String("rendezvous"); server != "" {
+		if _, err = local.UseRendezvous(server, c.String("rendezvous-namespace")); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("broadcast") {
+		local.UseBroadcast(c.Int("broadcast-port"))
+	}
+
+	// Only look around without announcing ourselves.
+	if c.Bool("all") {
+		local.SetMdnsServices("", commons.MdnsServiceReceive, commons.MdnsServiceSend, commons.ServiceTag)
+	} else {
+		local.SetMdnsServices("", commons.MdnsServiceReceive)
+	}
+
+	log.Infoln("Searching peers in your local network...")
+	err = local.Discovery.Start(ctx)
+	if err != nil {
+		return err
+	}
+	defer local.Discovery.Stop()
+
+	time.Sleep(c.Duration("wait"))
+
+	peers := local.Discovery.PeersList()
+	if len(peers) == 0 {
+		log.Infoln("No peer found in your local network")
+		return nil
+	}
+
+	log.Infof("\nFound the following peer(s):\n")
+	local.Discovery.PrintPeers(peers)
+
+	return nil
+}
+
+// BlockAction adds the peer given as the first argument to the blocklist.
+func BlockAction(c *cli.Context) error {
+	conf, peerID, err := loadPeerArg(c)
+	if err != nil {
+		return err
+	}
+
+	if !conf.Blocklist.Add(peerID.String()) {
+		log.Infof("Peer %s is already blocked\n", peerID)
+		return nil
+	}
+
+	if err = conf.Blocklist.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Blocked peer %s\n", peerID)
+	return nil
+}
+
+// UnblockAction removes the peer given as the first argument from the blocklist.
+func UnblockAction(c *cli.Context) error {
+	conf, peerID, err := loadPeerArg(c)
+	if err != nil {
+		return err
+	}
+
+	if !conf.Blocklist.Remove(peerID.String()) {
+		return fmt.Errorf("peer %s is not blocked", peerID)
+	}
+
+	if err = conf.Blocklist.Save(); err != nil {
+		return err
+	}
+
+	log.Infof("Unblocked peer %s\n", peerID)
+	return nil
+}
+
+// BlockedAction prints all blocked peers.
+func BlockedAction(c *cli.Context) error {
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Blocklist.PeerIDs) == 0 {
+		log.Infoln("No peer is blocked")
+		return nil
+	}
+
+	for _, id := range conf.Blocklist.PeerIDs {
+		if contact, found := conf.AddressBook.ByPeerID(id); found {
+			log.Infof("%s (%s)\n", id, contact.Name)
+		} else {
+			log.Infoln(id)
+		}
+	}
+	return nil
+}
+
+// loadPeerArg loads the configuration and resolves the first
+// argument, either a peer ID or the name of a contact.
+func loadPeerArg(c *cli.Context) (*config.Config, peer.ID, error) {
+	arg := c.Args().First()
+	if arg == "" {
+		return nil, "", fmt.Errorf("please specify the peer ID or contact name of the peer")
+	}
+
+	conf, err := config.LoadConfig(c.String("profile"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if contact, found := conf.AddressBook.Lookup(arg); found {
+		arg = contact.PeerID
+	}
+
+	peerID, err := peer.Decode(arg)
+	if err != nil {
+		return nil, "", fmt.Errorf("%q is neither a peer ID nor a contact: %w", arg, err)
+	}
+
+	return conf, peerID, nil
+}
@@ -0,0 +1,90 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:        "profile",
+	Usage:       "Manages isolated sets of settings and identities.",
+	Description: `A profile bundles settings and a peer identity. Select it for other commands with --profile NAME or P2P_PROFILE.`,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "Lists all available profiles.",
+			Action: ListAction,
+		},
+		{
+			Name:      "create",
+			Usage:     "Creates a new profile with a fresh identity.",
+			ArgsUsage: "NAME",
+			Action:    CreateAction,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Deletes a profile including its identity.",
+			ArgsUsage: "NAME",
+			Action:    DeleteAction,
+		},
+	},
+}
+
+// ListAction prints the names of all profiles and marks the selected one.
+func ListAction(c *cli.Context) error {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return err
+	}
+
+	selected := c.String("profile")
+	if selected == "" {
+		selected = config.DefaultProfile
+	}
+
+	for _, p := range profiles {
+		if p == selected {
+			fmt.Printf("* %s\n", p)
+		} else {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+
+	return nil
+}
+
+// CreateAction creates the profile given as the first argument.
+func CreateAction(c *cli.Context) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("please specify the name of the profile")
+	}
+
+	conf, err := config.CreateProfile(name)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Created profile %s at %s\n", name, conf.Settings.Path)
+	return nil
+}
+
+// DeleteAction deletes the profile given as the first argument.
+func DeleteAction(c *cli.Context) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("please specify the name of the profile")
+	}
+
+	if err := config.DeleteProfile(name); err != nil {
+		return err
+	}
+
+	log.Infof("Deleted profile %s\n", name)
+	return nil
+}
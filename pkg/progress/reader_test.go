@@ -55,7 +55,7 @@ func TestReader_N(t *testing.T) {
 			// Create a new Reader with the buffer
 			reader := NewReader(buffer)
 			// Read from the reader
-			_ = make([]byte, tc.readSize)
+			_, _ = reader.Read(make([]byte, tc.readSize))
 			// Verify the number of bytes read
 			if reader.N() != tc.expectedN {
 				t.Errorf("Expected N() to return %d, got %d", tc.expectedN, reader.N())
@@ -81,7 +81,7 @@ func TestReader_Err(t *testing.T) {
 			// Create a new Reader with the buffer
 			reader := NewReader(buffer)
 			// Read from the reader
-			_ = make([]byte, tc.readSize)
+			_, _ = reader.Read(make([]byte, tc.readSize))
 			// Verify the error
 			if tc.expectedErrNil && reader.Err() != nil {
 				t.Errorf("Expected Err() to return nil, got %v", reader.Err())
@@ -0,0 +1,206 @@
+package pull
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/format"
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// ListCommand .
+var ListCommand = &cli.Command{
+	Name:      "ls",
+	Usage:     "Lists the files a peer shares.",
+	Action:    ListAction,
+	Flags:     flags,
+	ArgsUsage: "PEER[:PATH]",
+	Description: `The ls subcommand lists the files and directories in the given directory of the peer
+given by a multiaddress or address book contact, e.g. alice:reports/2024. The peer only
+answers if you are authorized.`,
+}
+
+// GetCommand .
+var GetCommand = &cli.Command{
+	Name:   "get",
+	Usage:  "Downloads a file or directory a peer shares.",
+	Action: GetAction,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Save the file or directory as `PATH` instead of under its shared name in the current directory.",
+		},
+	}, flags...),
+	ArgsUsage: "PEER:PATH",
+	Description: `The get subcommand downloads the file or, including all of its content, the directory
+at the given path of the peer given by a multiaddress or address book contact, e.g.
+alice:reports/2024/q1.pdf. Existing files aren't overwritten.`,
+}
+
+// ListAction is the function that is called when running p2p ls.
+func ListAction(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("please specify the peer and optionally the directory to list")
+	}
+
+	target, dir := node.SplitPeerPath(c.Args().First())
+	ctx, local, peerID, err := connect(c, target)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	var offset int64
+	for {
+		resp, err := local.List(ctx, peerID, dir, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range resp.Entries {
+			printEntry(tw, entry)
+		}
+		if err = tw.Flush(); err != nil {
+			return err
+		}
+
+		offset += int64(len(resp.Entries))
+		if len(resp.Entries) == 0 || offset >= resp.Total {
+			if resp.Total == 0 {
+				log.Infoln("The directory is empty")
+			}
+			return nil
+		}
+	}
+}
+
+// printEntry prints the size, modification time, name and content ID
+// of the given entry.
+func printEntry(tw *tabwriter.Writer, entry *p2p.ListEntry) {
+	modTime := time.Unix(entry.ModTime, 0).Format("2006-01-02 15:04")
+	if entry.Dir {
+		fmt.Fprintf(tw, "%s\t%s\t%s/\t\n", "-", modTime, entry.Name)
+		return
+	}
+
+	var cStr string
+	if c, err := cid.Cast(entry.Cid); err != nil {
+		cStr = err.Error()
+	} else {
+		cStr = c.String()
+	}
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", format.Bytes(entry.Size), modTime, entry.Name, cStr)
+}
+
+// GetAction is the function that is called when running p2p get.
+func GetAction(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("please specify the peer and the path to download")
+	}
+
+	target, sharePath := node.SplitPeerPath(c.Args().First())
+	sharePath = node.CleanSharePath(sharePath)
+
+	ctx, local, peerID, err := connect(c, target)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	// The root of the share isn't an entry of another directory.
+	entry := &p2p.ListEntry{Name: ".", Dir: true}
+	if sharePath != "" {
+		if entry, err = lookup(ctx, local, peerID, sharePath); err != nil {
+			return err
+		}
+	}
+
+	output := c.String("output")
+	if output == "" {
+		output = path.Base(sharePath)
+	}
+
+	if err = get(ctx, local, peerID, sharePath, entry, output); err != nil {
+		return err
+	}
+
+	log.Infoln("Successfully downloaded!")
+	return nil
+}
+
+// lookup returns the entry at the given path of the share.
+func lookup(ctx context.Context, local *node.Node, peerID peer.ID, sharePath string) (*p2p.ListEntry, error) {
+	entries, err := listAll(ctx, local, peerID, node.CleanSharePath(path.Dir(sharePath)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == path.Base(sharePath) {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("peer %s doesn't share %q", peerID, sharePath)
+}
+
+// listAll requests all pages of the given directory of the share.
+func listAll(ctx context.Context, local *node.Node, peerID peer.ID, dir string) ([]*p2p.ListEntry, error) {
+	var entries []*p2p.ListEntry
+	for {
+		resp, err := local.List(ctx, peerID, dir, int64(len(entries)))
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, resp.Entries...)
+		if len(resp.Entries) == 0 || int64(len(entries)) >= resp.Total {
+			return entries, nil
+		}
+	}
+}
+
+// get downloads the given entry at the given path of the share into
+// the given local path. Directories are downloaded recursively.
+func get(ctx context.Context, local *node.Node, peerID peer.ID, sharePath string, entry *p2p.ListEntry, output string) error {
+	if !entry.Dir {
+		c, err := cid.Cast(entry.Cid)
+		if err != nil {
+			return err
+		}
+		return pull(ctx, local, peerID, c, output)
+	}
+
+	entries, err := listAll(ctx, local, peerID, sharePath)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		// Don't trust the peer to send sane names.
+		if e.Name != filepath.Base(e.Name) || e.Name == ".." || e.Name == "." {
+			return fmt.Errorf("peer %s shares an invalid name %q", peerID, e.Name)
+		}
+
+		if err = get(ctx, local, peerID, path.Join(sharePath, e.Name), e, filepath.Join(output, e.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
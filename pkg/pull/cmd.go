@@ -0,0 +1,155 @@
+package pull
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/format"
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+	"github.com/ansuman12chat/p2p/pkg/progress"
+)
+
+// flags configure how the peer is reached.
+var flags = append([]cli.Flag{commons.SwarmKeyFlag}, commons.RelayFlags...)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "pull",
+	Usage:  "Requests a file by its content ID from a peer that shares it.",
+	Action: Action,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Save the file as `FILE` instead of under its shared name in the current directory.",
+		},
+	}, flags...),
+	ArgsUsage: "PEER CID",
+	Description: `The pull subcommand requests the file with the given content ID from the peer given by
+a multiaddress or address book contact. Only peers that run p2p share serve files.
+The peer only sends it if you are authorized, and the received data is checked
+against the content ID.`,
+}
+
+// Action is the function that is called when running p2p pull.
+func Action(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("please specify the peer and the content ID of the file")
+	}
+
+	contentID, err := cid.Decode(c.Args().Get(1))
+	if err != nil {
+		return errors.Wrap(err, "invalid content ID")
+	}
+
+	ctx, local, peerID, err := connect(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	if err = pull(ctx, local, peerID, contentID, c.String("output")); err != nil {
+		return err
+	}
+
+	log.Infoln("Successfully pulled file!")
+	return nil
+}
+
+// connect connects to the peer given by a multiaddress or address
+// book contact.
+func connect(c *cli.Context, target string) (context.Context, *node.Node, peer.ID, error) {
+	ctx, err := config.FillContext(c.Context, c.String("profile"))
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "failed loading configuration")
+	}
+
+	commons.ApplySwarmKeyFlag(ctx, c)
+	conf, _ := config.FromContext(ctx)
+
+	pi, err := node.ResolvePeer(conf.AddressBook, target)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	local, err := node.Init(ctx)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "failed to init node")
+	}
+
+	if relay := c.String("relay"); relay != "" {
+		if err = local.UseRelay(relay); err != nil {
+			local.Close()
+			return nil, nil, "", err
+		}
+	}
+
+	log.Infof("Connecting to %s...\n", pi.ID)
+	if err = local.Connect(ctx, local.ViaRelay(pi)); err != nil {
+		local.Close()
+		return nil, nil, "", err
+	}
+
+	return ctx, local, pi.ID, nil
+}
+
+// pull saves the content with the given content ID into a new file at
+// the given path, or under its shared name if the path is empty.
+func pull(ctx context.Context, local *node.Node, peerID peer.ID, contentID cid.Cid, path string) error {
+	content, err := local.Pull(ctx, peerID, contentID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if path == "" {
+		path = filepath.Base(content.Filename)
+	}
+
+	log.Infof("Pulling %s (%s) into %s\n", content.Filename, format.Bytes(content.Size), path)
+	return save(path, content)
+}
+
+// save writes the given content into a new file at the given path.
+// The file is removed again if the content turns out to be invalid.
+func save(path string, content *node.Content) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	pw := progress.NewWriter(f)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go node.IndicateProgress(ctx, pw, content.Filename, content.Size, &wg)
+
+	_, err = io.Copy(pw, content)
+	cancel()
+	wg.Wait()
+	log.Infoln()
+
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		os.Remove(path)
+		return errors.Wrap(err, "could not pull file from peer")
+	}
+	return nil
+}
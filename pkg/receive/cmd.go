@@ -1,7 +1,6 @@
 package receive
 
 import (
-	"encoding/hex"
 	"fmt"
 
 	"github.com/ipfs/go-cid"
@@ -9,7 +8,9 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
 	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
 	p2p "github.com/ansuman12chat/p2p/pkg/pb"
 )
 
@@ -18,57 +19,205 @@ var Command = &cli.Command{
 	Usage:   "waits until a peer attempts to connect in your local network to receive your a file",
 	Aliases: []string{"r"},
 	Action:  Action,
-	Flags: []cli.Flag{
+	Flags: append(append([]cli.Flag{
 		&cli.Int64Flag{
 			Name:    "port",
 			EnvVars: []string{"P2P_PORT"},
 			Aliases: []string{"p"},
-			Usage:   "The port at which you are reachable for other peers in the network.",
+			Usage:   "The TCP and UDP (QUIC) port at which you are reachable for other peers in the network. 0 picks a free port.",
 			Value:   44044,
 		},
 		&cli.StringFlag{
 			Name:    "host",
 			EnvVars: []string{"P2P_HOST"},
-			Usage:   "The host at which you are reachable for other peers in the network.",
+			Usage:   "The IPv4 host at which you are reachable for other peers in the network. Empty disables IPv4.",
 			Value:   "0.0.0.0",
 		},
-	},
-	ArgsUsage:   "[DEST_DIR]",
-	UsageText:   ``,
-	Description: `The receive subcommand will wait for a peer to connect to your node and receive a file.`,
+		&cli.StringFlag{
+			Name:    "host6",
+			EnvVars: []string{"P2P_HOST6"},
+			Usage:   "The IPv6 host at which you are reachable for other peers in the network. Empty disables IPv6.",
+			Value:   "::",
+		},
+		&cli.StringSliceFlag{
+			Name:    "listen",
+			EnvVars: []string{"P2P_LISTEN"},
+			Usage:   "Listen on the given multiaddress instead of the --host, --host6 and --port combination. Can be repeated.",
+		},
+		&cli.StringFlag{
+			Name:    "visibility",
+			EnvVars: []string{"P2P_VISIBILITY"},
+			Usage:   "Who can discover you: everyone, contacts (address book only) or hidden (explicit address only).",
+			Value:   string(VisibilityEveryone),
+		},
+		&cli.DurationFlag{
+			Name:    "visible-for",
+			EnvVars: []string{"P2P_VISIBLE_FOR"},
+			Usage:   "Hide from the local network after the given duration (e.g. 10m). Only applies to visibility everyone.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "content-dir",
+			EnvVars: []string{"P2P_CONTENT_DIR"},
+			Usage:   "Skip the transfer if a file with the same content is found below the given directory. Can be repeated.",
+		},
+		&cli.StringFlag{
+			Name:    "existing",
+			EnvVars: []string{"P2P_EXISTING"},
+			Usage:   "How the offered file is created if its content is found under another name: copy (default), link (a hard link that shares the data with the original) or none.",
+		},
+		commons.SwarmKeyFlag,
+	}, commons.DiscoveryFlags...), commons.RelayFlags...),
+	ArgsUsage: "[CODE]",
+	UsageText: ``,
+	Description: `The receive subcommand will wait for a peer to connect to your node and receive a file.
+If the sender printed a pairing code like 7-purple-sausage, pass it as argument to only
+receive the file of that sender.
+
+If you already have the file, in the directory it's saved to or below one of the
+--content-dir directories, the transfer is skipped once you accept it. The file is
+then copied or linked under the offered name, see --existing.`,
 }
 
 // Action is the function that is called when running p2p receive.
 func Action(c *cli.Context) error {
 	shutdown := make(chan error)
 
-	ctx, err := config.FillContext(c.Context)
+	ctx, err := config.FillContext(c.Context, c.String("profile"))
 	if err != nil {
 		return errors.Wrap(err, "failed loading configuration")
 	}
 
-	local, err := InitNode(ctx, c.String("host"), c.Int64("port"), shutdown)
+	commons.ApplySwarmKeyFlag(ctx, c)
+	if conf, ok := config.FromContext(ctx); ok {
+		conf.Settings.ContentDirs = append(conf.Settings.ContentDirs, c.StringSlice("content-dir")...)
+		if existing := c.String("existing"); existing != "" {
+			conf.Settings.ExistingContent = existing
+		}
+	}
+
+	visibility, err := ParseVisibility(c.String("visibility"))
+	if err != nil {
+		return err
+	}
+
+	code := c.Args().First()
+	if code != "" {
+		if code, _, err = node.ParsePairingCode(code); err != nil {
+			return err
+		}
+	}
+
+	listenAddrs := c.StringSlice("listen")
+	if len(listenAddrs) == 0 {
+		listenAddrs = ListenAddrs(ctx, c.String("host"), c.String("host6"), c.Int64("port"))
+	}
+
+	local, err := InitNode(ctx, listenAddrs, shutdown)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to initialize node"))
 	}
 	defer local.Close()
 
+	if err = local.loadContentIndex(c.String("profile")); err != nil {
+		return errors.Wrap(err, "failed loading content index")
+	}
+
+	if relay := c.String("relay"); relay != "" {
+		if err = local.UseRelay(relay); err != nil {
+			return err
+		}
+		if err = local.ReserveRelay(ctx); err != nil {
+			return err
+		}
+		defer local.StopRelay()
+	}
+
 	log.Infof("Your identity:\n\n\t%s\n\n", local.Host.ID())
 
-	err = local.StartMdnsService(ctx)
+	addrs, err := local.DialableAddrs()
 	if err != nil {
 		return err
 	}
-	defer local.StopMdnsService()
+	log.Infoln("Your addresses:")
+	log.Infoln()
+	for _, addr := range addrs {
+		log.Infof("\t%s\n", addr)
+	}
+	log.Infoln()
+
+	local.SetVisibility(visibility)
+
+	// Discoverers besides mDNS that announce the receiver.
+	var announcers []node.Discoverer
+	if server := c.String("rendezvous"); server != "" {
+		rdv, err := local.UseRendezvous(server, c.String("rendezvous-namespace"))
+		if err != nil {
+			return err
+		}
+		announcers = append(announcers, rdv)
+	}
+	if c.Bool("broadcast") {
+		announcers = append(announcers, local.UseBroadcast(c.Int("broadcast-port")))
+	}
 
+	// The paired sender may push right after the pairing, before it
+	// completed on our side. Until then, only it is let through.
+	if code != "" {
+		local.expectPairing()
+	}
 	local.RegisterRequestHandler(local)
 
+	if code != "" {
+		peerID, err := local.PairWithSender(ctx, code)
+		if err != nil {
+			return err
+		}
+		log.Infof("Paired with %s\n", peerID)
+	}
+
+	switch {
+	case visibility == VisibilityHidden:
+		log.Infoln("You are hidden from the local network. Peers need your address to connect.")
+	case visibility == VisibilityEveryone && c.Duration("visible-for") > 0:
+		log.Infof("You are visible to everyone for %s.\n", c.Duration("visible-for"))
+		err = local.StartMdnsServiceFor(ctx, c.Duration("visible-for"), func() {
+			for _, a := range announcers {
+				if err := a.Stop(); err != nil {
+					log.Infoln(err)
+				}
+			}
+			log.Infoln("\nYou are now hidden from the local network.")
+		})
+	default:
+		if visibility == VisibilityContacts {
+			log.Infoln("Only peers in your address book can see your nickname and send you files.")
+			log.Infoln("Your peer ID and addresses are still announced to the local network.")
+		}
+		err = local.StartMdnsService(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer local.StopMdnsService()
+
+	if visibility != VisibilityHidden {
+		for _, a := range announcers {
+			if err = a.Start(ctx); err != nil {
+				return err
+			}
+			defer a.Stop()
+		}
+	}
+
 	log.Infoln("Ready to receive files... (cancel with ctrl+c)")
 
 	return <-shutdown
 }
 
-func printInformation(data *p2p.PushRequest) {
+// printInformation prints the details of the given request including the
+// short authentication string sas, if any, whether the sender is verified
+// and its certificate.
+func printInformation(data *p2p.PushRequest, sas string, verified bool, cert *p2p.Certificate) {
 
 	var cStr string
 	if c, err := cid.Cast(data.Cid); err != nil {
@@ -82,13 +231,24 @@ func printInformation(data *p2p.PushRequest) {
 	log.Infoln("\tName:\t", data.Filename)
 	log.Infoln("\tSize:\t", data.Size)
 	log.Infoln("\tCID:\t", cStr)
-	log.Infoln("\tSign:\t", hex.EncodeToString(data.Header.Signature))
-	log.Infoln("\tPubKey:\t", hex.EncodeToString(data.Header.GetNodePubKey()))
+	if sas != "" {
+		log.Infoln("\tCode:\t", sas)
+	}
+	if cert != nil {
+		log.Infoln("\tSender:\t certified as", describeCertificate(cert), "by", cert.Issuer)
+	} else if verified {
+		log.Infoln("\tSender:\t verified")
+	} else if sas != "" {
+		log.Infoln("\tSender:\t not verified, compare the code with the one on the sender's screen")
+	} else {
+		log.Infoln("\tSender:\t not verified")
+	}
 }
 
 func help() {
 	log.Infoln("y: accept and thus accept the file")
-	log.Infoln("n: reject the request to accept the file")
+	log.Infoln("v: the sender shows the same code, mark it as verified and accept the file")
+	log.Infoln("n: reject the request to accept the file, e.g. if the codes don't match")
 	log.Infoln("i: show information about the sender and file to be received")
 	log.Infoln("q: quit p2p")
 	log.Infoln("?: this help message")
@@ -0,0 +1,295 @@
+package receive
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// ExistingContent controls how an offered file is created if the
+// receiver already has its content under another name.
+type ExistingContent string
+
+const (
+	// ExistingLink creates a hard link to the existing file, or a copy
+	// if that isn't possible, e.g. across file systems. Both names then
+	// refer to the same data, so changing one file changes the other.
+	ExistingLink ExistingContent = "link"
+
+	// ExistingCopy creates a copy of the existing file.
+	ExistingCopy ExistingContent = "copy"
+
+	// ExistingNone doesn't create the offered file.
+	ExistingNone ExistingContent = "none"
+)
+
+// ParseExistingContent converts the given setting or command line value
+// into an ExistingContent. Empty values mean ExistingCopy.
+func ParseExistingContent(s string) (ExistingContent, error) {
+	switch e := ExistingContent(s); e {
+	case "":
+		return ExistingCopy, nil
+	case ExistingLink, ExistingCopy, ExistingNone:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown handling of existing content %q, expected one of copy, link or none", s)
+	}
+}
+
+// contentIndex caches the content IDs of local files in the content
+// index of the profile, so unchanged files are only hashed once. A nil
+// contentIndex hashes the files every time.
+type contentIndex struct {
+	lk      sync.Mutex // protects index and changed
+	index   *config.ContentIndex
+	changed bool
+}
+
+// findContent returns the path of a file with the given content ID
+// and size, or an empty path if there's none. It checks the given path
+// first, then the other files in its directory and then all files
+// below the given content directories. Only files of the given size
+// are hashed, unless their content ID is indexed already.
+func (ci *contentIndex) findContent(c cid.Cid, size int64, path string, contentDirs []string) (string, error) {
+	defer ci.save()
+
+	if ci.hasContent(path, c, size) {
+		return path, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, entry := range entries {
+		candidate := filepath.Join(filepath.Dir(path), entry.Name())
+		if candidate != path && ci.hasContent(candidate, c, size) {
+			return candidate, nil
+		}
+	}
+
+	for _, dir := range contentDirs {
+		var found string
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip what we can't read instead of giving up.
+				log.Infoln(err)
+				return nil
+			}
+			if !d.IsDir() && ci.hasContent(p, c, size) {
+				found = p
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		} else if found != "" {
+			return found, nil
+		}
+	}
+
+	return "", nil
+}
+
+// hasContent returns true if the given path is a regular file with the
+// given size and content ID.
+func (ci *contentIndex) hasContent(path string, c cid.Cid, size int64) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != size {
+		return false
+	}
+
+	actual, err := ci.contentID(path, info)
+	if err != nil {
+		log.Infoln(err)
+		return false
+	}
+	return actual.Equals(c)
+}
+
+// contentID returns the content ID of the regular file at the given
+// path with the given info. It's taken from the index if the file
+// didn't change since it was indexed.
+func (ci *contentIndex) contentID(path string, info fs.FileInfo) (cid.Cid, error) {
+	if ci == nil {
+		return node.FileContentID(path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	ci.lk.Lock()
+	indexed, found := ci.index.Lookup(abs, info.Size(), info.ModTime())
+	ci.lk.Unlock()
+	if found {
+		if c, err := cid.Decode(indexed); err == nil {
+			return c, nil
+		}
+	}
+
+	c, err := node.FileContentID(path)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	ci.lk.Lock()
+	ci.index.Add(abs, info.Size(), info.ModTime(), c.String())
+	ci.changed = true
+	ci.lk.Unlock()
+	return c, nil
+}
+
+// update indexes all files below the given directories and forgets
+// the indexed files that are gone, so later lookups don't need to
+// hash them.
+func (ci *contentIndex) update(dirs []string) {
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if _, err = ci.contentID(p, info); err != nil {
+				log.Infoln(err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Infoln(err)
+		}
+		ci.save()
+	}
+
+	ci.lk.Lock()
+	for path := range ci.index.Files {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			ci.changed = ci.index.Remove(path) || ci.changed
+		}
+	}
+	ci.lk.Unlock()
+	ci.save()
+}
+
+// forget removes the file at the given path from the index, e.g.
+// because its content changed without changing its size and
+// modification time.
+func (ci *contentIndex) forget(path string) {
+	if ci == nil {
+		return
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	ci.lk.Lock()
+	ci.changed = ci.index.Remove(abs) || ci.changed
+	ci.lk.Unlock()
+	ci.save()
+}
+
+// save persists the index if it changed.
+func (ci *contentIndex) save() {
+	if ci == nil {
+		return
+	}
+
+	ci.lk.Lock()
+	defer ci.lk.Unlock()
+	if !ci.changed {
+		return
+	}
+	if err := ci.index.Save(); err != nil {
+		log.Infoln("Could not save the content index:", err)
+		return
+	}
+	ci.changed = false
+}
+
+// placeContent creates the file at the given path from the existing
+// file at the given source path, which was found to have the given
+// content ID. The index can be outdated, e.g. if the file was rewritten
+// without changing its size and modification time, so its content is
+// hashed again, while it's copied if it is. ErrContentMismatch is
+// returned if it doesn't match. Existing files are never replaced.
+func placeContent(src string, path string, e ExistingContent, c cid.Cid) error {
+	if src == path || e == ExistingNone {
+		return verifyContent(src, c)
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists and is kept", path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if e == ExistingLink {
+		if err := verifyContent(src, c); err != nil {
+			return err
+		}
+		if err := os.Link(src, path); err == nil {
+			return nil
+		}
+	}
+	return copyFile(src, path, c)
+}
+
+// verifyContent returns ErrContentMismatch if the file at the given
+// path doesn't have the given content ID.
+func verifyContent(path string, c cid.Cid) error {
+	actual, err := node.FileContentID(path)
+	if err != nil {
+		return err
+	} else if !actual.Equals(c) {
+		return fmt.Errorf("%w: %s has content %s instead of %s", node.ErrContentMismatch, path, actual, c)
+	}
+	return nil
+}
+
+// copyFile copies the file at the given source path to the given path,
+// which must not exist yet. The copy is removed if it doesn't have the
+// given content ID.
+func copyFile(src string, path string, c cid.Cid) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := node.NewVerifiedReader(in, c)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	return out.Close()
+}
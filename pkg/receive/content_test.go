@@ -0,0 +1,142 @@
+package receive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// writeContent writes the given data to the given path and returns
+// its content ID.
+func writeContent(t *testing.T, path string, data string) cid.Cid {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	c, err := node.FileContentID(path)
+	require.NoError(t, err)
+	return c
+}
+
+// tempContentIndex returns an empty content index that is saved to a
+// temporary directory.
+func tempContentIndex(t *testing.T) *contentIndex {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+
+	index, err := config.LoadContentIndex("")
+	require.NoError(t, err)
+	return &contentIndex{index: index}
+}
+
+func TestContentIndex_findContent(t *testing.T) {
+	dir := t.TempDir()
+	contentDir := t.TempDir()
+	c := writeContent(t, filepath.Join(contentDir, "sub", "photo.jpg"), "photo")
+	writeContent(t, filepath.Join(contentDir, "other.jpg"), "other")
+
+	for name, ci := range map[string]*contentIndex{"unindexed": nil, "indexed": tempContentIndex(t)} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, "photo.jpg")
+
+			found, err := ci.findContent(c, 5, path, []string{contentDir})
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(contentDir, "sub", "photo.jpg"), found)
+
+			// Only the directory of the path and the content directories
+			// are searched.
+			found, err = ci.findContent(c, 5, filepath.Join(dir, "missing.jpg"), nil)
+			require.NoError(t, err)
+			assert.Empty(t, found)
+
+			other := writeContent(t, filepath.Join(dir, "other.jpg"), "p2p!!")
+			found, err = ci.findContent(other, 5, path, []string{contentDir})
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, "other.jpg"), found)
+			require.NoError(t, os.Remove(filepath.Join(dir, "other.jpg")))
+		})
+	}
+}
+
+func TestContentIndex_findContent_miss(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, filepath.Join(dir, "a.txt"), "aaaa")
+	writeContent(t, filepath.Join(dir, "b.txt"), "bbbb")
+	wanted, err := node.ContentID(strings.NewReader("cccc"))
+	require.NoError(t, err)
+
+	found, err := tempContentIndex(t).findContent(wanted, 4, filepath.Join(dir, "c.txt"), []string{dir})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestPlaceContent(t *testing.T) {
+	for _, e := range []ExistingContent{ExistingCopy, ExistingLink} {
+		t.Run(string(e), func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src.txt")
+			c := writeContent(t, src, "content")
+
+			path := filepath.Join(dir, "sub", "dst.txt")
+			require.NoError(t, placeContent(src, path, e, c))
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(data))
+		})
+	}
+}
+
+func TestPlaceContent_keepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	c := writeContent(t, src, "content")
+	path := filepath.Join(dir, "dst.txt")
+	writeContent(t, path, "mine")
+
+	for _, e := range []ExistingContent{ExistingCopy, ExistingLink} {
+		assert.Error(t, placeContent(src, path, e, c))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "mine", string(data))
+	}
+}
+
+func TestPlaceContent_rejectsChangedContent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	writeContent(t, src, "content")
+
+	// The file was rewritten with the same size and modification time
+	// after it was indexed with the content ID of the sender.
+	info, err := os.Stat(src)
+	require.NoError(t, err)
+	ci := tempContentIndex(t)
+	wanted, err := node.ContentID(strings.NewReader("CONTENT"))
+	require.NoError(t, err)
+	abs, err := filepath.Abs(src)
+	require.NoError(t, err)
+	ci.index.Add(abs, info.Size(), info.ModTime(), wanted.String())
+
+	path := filepath.Join(dir, "dst.txt")
+	found, err := ci.findContent(wanted, info.Size(), path, []string{dir})
+	require.NoError(t, err)
+	require.Equal(t, src, found)
+
+	for _, e := range []ExistingContent{ExistingCopy, ExistingLink, ExistingNone} {
+		assert.ErrorIs(t, placeContent(src, path, e, wanted), node.ErrContentMismatch, e)
+		assert.NoFileExists(t, path)
+	}
+
+	ci.forget(src)
+	found, err = ci.findContent(wanted, info.Size(), path, []string{dir})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
@@ -5,59 +5,162 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
 
 	"github.com/ansuman12chat/p2p/internal/format"
 	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
 	"github.com/ansuman12chat/p2p/pkg/node"
 	p2p "github.com/ansuman12chat/p2p/pkg/pb"
 )
 
+// The time to wait for a relayed sender to hang up after a transfer.
+var relayHangUpTimeout = 3 * time.Second
+
+// The time to wait for the sender to hang up after it was told that
+// the transfer is skipped. Shutting down earlier would drop the answer.
+var skipHangUpTimeout = 3 * time.Second
+
 type Node struct {
 	*node.Node
-	busy     *atomic.Bool
-	shutdown chan error
+	busy       *atomic.Bool
+	shutdown   chan error
+	visibility Visibility
+	contacts   *config.AddressBook
+
+	// The directory that files from linked devices are saved to.
+	linkedDir string
+
+	// Where to look for files we already have and how to create
+	// the offered file from them, see Settings.ContentDirs.
+	contentDirs []string
+	existing    ExistingContent
+	index       *contentIndex
+
+	// Set while pairing with a sender, see PairWithSender.
+	pairing atomic.Pointer[pairingState]
 }
 
-func InitNode(ctx context.Context, host string, port int64, shutdown chan error) (*Node, error) {
+func InitNode(ctx context.Context, listenAddrs []string, shutdown chan error) (*Node, error) {
 
-	hostAddr := fmt.Sprintf("/ip4/%s/tcp/%d", host, port)
 	// Create a new node
-	nn, err := node.Init(ctx, libp2p.ListenAddrStrings(hostAddr))
+	nn, err := node.Init(ctx, libp2p.ListenAddrStrings(listenAddrs...))
 	if err != nil {
 		return nil, err
 	}
 	n := &Node{
-		Node:     nn,
-		busy:     &atomic.Bool{},
-		shutdown: shutdown,
+		Node:       nn,
+		busy:       &atomic.Bool{},
+		shutdown:   shutdown,
+		visibility: VisibilityEveryone,
+		existing:   ExistingCopy,
+	}
+
+	if conf, ok := config.FromContext(ctx); ok {
+		n.contacts = conf.AddressBook
+		n.linkedDir = conf.Settings.LinkedDevicesDir
+		n.contentDirs = conf.Settings.ContentDirs
+		if n.existing, err = ParseExistingContent(conf.Settings.ExistingContent); err != nil {
+			nn.Close()
+			return nil, err
+		}
 	}
 
 	// Setting the value to false, because we are not busy yet.
 	// We are only busy when we are receiving a file. Deafult value is false.
 	n.busy.Store(false)
-	n.RegisterRequestHandler(n)
+	n.SetRole(node.RoleReceiver)
+	n.SetMdnsServices(commons.MdnsServiceReceive)
 	return n, nil
 }
 
+// ListenAddrs returns TCP and QUIC listen addresses for the given IPv4
+// and IPv6 hosts. Empty hosts are skipped. A port of 0 lets the operating
+// system choose a free one. QUIC is left out if the configuration in the
+// given context has a swarm key, as QUIC doesn't support private swarms.
+func ListenAddrs(ctx context.Context, host string, host6 string, port int64) []string {
+	quic := true
+	if conf, ok := config.FromContext(ctx); ok && conf.Settings.SwarmKey != "" {
+		quic = false
+	}
+
+	var addrs []string
+	if host != "" {
+		addrs = append(addrs, fmt.Sprintf("/ip4/%s/tcp/%d", host, port))
+		if quic {
+			addrs = append(addrs, fmt.Sprintf("/ip4/%s/udp/%d/quic-v1", host, port))
+		}
+	}
+	if host6 != "" {
+		addrs = append(addrs, fmt.Sprintf("/ip6/%s/tcp/%d", host6, port))
+		if quic {
+			addrs = append(addrs, fmt.Sprintf("/ip6/%s/udp/%d/quic-v1", host6, port))
+		}
+	}
+	return addrs
+}
+
 func (n *Node) Shutdown(err error) {
 	n.shutdown <- err
 	close(n.shutdown)
 }
 
 func (n *Node) HandlePushRequest(pr *p2p.PushRequest) (bool, error) {
+	peerID, err := pr.PeerID()
+	if err != nil {
+		return false, err
+	}
+
+	trust := n.PeerTrust(peerID)
+	if !n.isAllowed(peerID, trust) {
+		log.Infof("Rejected push request from unknown peer %s\n", peerID)
+		return false, nil
+	}
+
 	if n.busy.Load() {
 		return false, nil
 	}
 	n.busy.Store(true)
 
-	log.Infof("Sending request: %s (%s)\n", pr.Filename, format.Bytes(pr.Size))
+	// Files from our own devices are accepted without asking.
+	if trust.Linked {
+		log.Infof("Receiving %s (%s) from your linked device %s\n", pr.Filename, format.Bytes(pr.Size), peerID)
+		return n.accept(peerID, n.linkedDir, true, pr)
+	}
+
+	if n.IsRelayed(peerID) {
+		log.Infof("Sending request: %s (%s) via relay\n", pr.Filename, format.Bytes(pr.Size))
+	} else {
+		log.Infof("Sending request: %s (%s)\n", pr.Filename, format.Bytes(pr.Size))
+	}
+
+	// Unverified senders are asked to compare the short
+	// authentication string that both peers display.
+	verified := trust.IsVerified()
+	cert := trust.Certificate
+	logCertificateError(peerID, trust.CertificateErr)
+	if cert != nil {
+		log.Infof("The sender is certified as %s.\n", describeCertificate(cert))
+	}
+
+	sas := n.shortAuthString(peerID, pr)
+	options := "[y,n,i,q,?]"
+	if !verified && sas != "" {
+		log.Infof("The sender isn't verified yet. Make sure it shows the code: %s\n", sas)
+		options = "[y,v,n,i,q,?]"
+	}
+
 	for {
-		log.Infof("Do you want to receive this file? [y,n,i,q,?] ")
+		log.Infof("Do you want to receive this file? %s ", options)
 		scanner := bufio.NewScanner(os.Stdin)
 		if !scanner.Scan() {
 			return true, errors.Wrap(scanner.Err(), "failed reading from stdin")
@@ -85,26 +188,23 @@ func (n *Node) HandlePushRequest(pr *p2p.PushRequest) (bool, error) {
 
 		// Print information about the send request
 		if input == "i" {
-			printInformation(pr)
+			printInformation(pr, sas, verified, cert)
 			continue
 		}
 
-		// Accept the file transfer
-		if input == "y" {
-
-			peerID, err := pr.PeerID()
-			if err != nil {
-				return true, err
-			}
-
-			done := n.TransferFinishHandler(pr.Size)
-			th, err := NewTransferHandler(peerID, pr.Filename, pr.Size, pr.Cid, done)
-			if err != nil {
-				return true, err
+		// The codes match, so verify the sender and accept the file transfer
+		if input == "v" && !verified && sas != "" {
+			if err := n.verify(peerID); err != nil {
+				log.Infoln("Could not mark the sender as verified:", err)
+			} else {
+				log.Infoln("Marked the sender as verified.")
 			}
-			n.RegisterTransferHandler(th)
+			input = "y"
+		}
 
-			return true, nil
+		// Accept the file transfer
+		if input == "y" {
+			return n.accept(peerID, "", false, pr)
 		}
 
 		// Reject the file transfer
@@ -118,7 +218,93 @@ func (n *Node) HandlePushRequest(pr *p2p.PushRequest) (bool, error) {
 	}
 }
 
-func (n *Node) TransferFinishHandler(size int64) chan int64 {
+// accept prepares the transfer of the file of the given request
+// into the given directory. The transfer is skipped if we already
+// have the file. If keep is true, the request is rejected instead
+// of replacing another file of the same name.
+func (n *Node) accept(peerID peer.ID, dir string, keep bool, pr *p2p.PushRequest) (bool, error) {
+	path := filepath.Join(dir, filepath.Base(pr.Filename))
+	if existing, c := n.lookUp(path, pr); existing != "" {
+		err := placeContent(existing, path, n.existing, c)
+		if !errors.Is(err, node.ErrContentMismatch) {
+			return n.skip(peerID, existing, path, err)
+		}
+		log.Infof("%s changed since it was indexed. The file is transferred.\n", existing)
+		n.index.forget(existing)
+	}
+
+	// Files that nobody confirmed never replace existing ones.
+	if _, err := os.Lstat(path); keep && err == nil {
+		log.Infof("Rejected %s, because %s already exists and is kept\n", pr.Filename, path)
+		n.busy.Store(false)
+		return false, nil
+	}
+
+	done := n.TransferFinishHandler(peerID, pr.Size)
+	th, err := NewTransferHandler(peerID, dir, pr.Filename, pr.Size, pr.Cid, done)
+	if err != nil {
+		return true, err
+	}
+	th.keep = keep
+	n.RegisterTransferHandler(th)
+
+	return true, nil
+}
+
+// loadContentIndex loads the content index of the given profile and
+// indexes the content directories in the background, so lookups don't
+// need to hash all of their files.
+func (n *Node) loadContentIndex(profile string) error {
+	index, err := config.LoadContentIndex(profile)
+	if err != nil {
+		return err
+	}
+
+	n.index = &contentIndex{index: index}
+	if len(n.contentDirs) > 0 {
+		go n.index.update(n.contentDirs)
+	}
+	return nil
+}
+
+// lookUp returns the path of a file with the content of the given
+// request, preferably the given path, or an empty path if we don't
+// have it, together with the content ID of the request. Only requests
+// that were accepted are looked up, so peers can't find out which
+// files we have.
+func (n *Node) lookUp(path string, pr *p2p.PushRequest) (string, cid.Cid) {
+	c, err := cid.Cast(pr.Cid)
+	if err != nil {
+		return "", cid.Undef
+	}
+
+	existing, err := n.index.findContent(c, pr.Size, path, n.contentDirs)
+	if err != nil {
+		log.Infoln("Could not look for the file among yours:", err)
+	}
+	return existing, c
+}
+
+// skip tells the sender that we already have the content at the given
+// path and shuts down like after a transfer. The given error tells why
+// the requested file couldn't be created at the given path, if it
+// couldn't.
+func (n *Node) skip(peerID peer.ID, existing string, path string, err error) (bool, error) {
+	log.Infof("You already have this file at %s. The transfer is skipped.\n", existing)
+	if err != nil {
+		log.Infoln("Could not create the file:", err)
+	} else if existing != path && n.existing != ExistingNone {
+		log.Infoln("Saved file to:", path)
+	}
+
+	go func() {
+		n.WaitForDisconnect(peerID, skipHangUpTimeout)
+		n.shutdown <- nil
+	}()
+	return false, node.ErrHaveContent
+}
+
+func (n *Node) TransferFinishHandler(peerID peer.ID, size int64) chan int64 {
 	done := make(chan int64)
 	go func() {
 		var received int64
@@ -128,10 +314,17 @@ func (n *Node) TransferFinishHandler(size int64) chan int64 {
 			return
 		}
 
+		// A negative count means that the file was corrupted.
 		if received == size {
 			log.Infoln("Successfully received file!")
-		} else {
-			log.Infoln("Only received %d of %d bytes!", received, size)
+		} else if received >= 0 {
+			log.Infof("Only received %d of %d bytes!\n", received, size)
+		}
+
+		// Tearing down the host right away may prevent the relay from
+		// forwarding our acknowledgment, so let the sender hang up first.
+		if n.IsRelayed(peerID) {
+			n.WaitForDisconnect(peerID, relayHangUpTimeout)
 		}
 
 		n.shutdown <- nil
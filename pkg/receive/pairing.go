@@ -0,0 +1,108 @@
+package receive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// The time after which senders that couldn't be asked are tried again,
+// e.g. because they weren't ready yet.
+var pairingRetryInterval = time.Second
+
+// The time a pairing attempt with a single sender may take.
+var pairingTimeout = 10 * time.Second
+
+// pairingState tracks the pairing with a sender. The sender may send
+// its push request before the pairing is completed on this side.
+type pairingState struct {
+	done   chan struct{}
+	peerID peer.ID
+}
+
+// isPaired waits for the pairing to complete and returns true
+// if the given peer is the paired one.
+func (s *pairingState) isPaired(peerID peer.ID) bool {
+	select {
+	case <-s.done:
+		return s.peerID == peerID
+	case <-time.After(pairingTimeout):
+		return false
+	}
+}
+
+// expectPairing makes the node only accept push requests of the sender
+// it's going to pair with, also before the pairing is completed.
+func (n *Node) expectPairing() *pairingState {
+	n.pairing.CompareAndSwap(nil, &pairingState{done: make(chan struct{})})
+	return n.pairing.Load()
+}
+
+// PairWithSender looks for the sender that displays the given code and
+// pairs with it. Afterwards, only push requests of that sender are
+// accepted. It must be called before the mDNS service is started.
+func (n *Node) PairWithSender(ctx context.Context, code string) (peer.ID, error) {
+	// Don't accept requests of other senders in the meantime.
+	state := n.expectPairing()
+
+	advertise := commons.MdnsServiceReceive
+	discovery := n.Discovery
+	if n.visibility == VisibilityHidden {
+		// Only browse, rendezvous servers and broadcasts would announce us.
+		advertise = ""
+		discovery = node.NewDiscovery(n.MDNSProtocol)
+		defer discovery.Stop()
+	}
+	n.SetMdnsServices(advertise, commons.MdnsServiceSend)
+
+	events, unsubscribe := discovery.Subscribe()
+	defer unsubscribe()
+
+	if err := discovery.Start(ctx); err != nil {
+		return "", err
+	}
+
+	log.Infoln("Looking for the sender with your code...")
+
+	// Senders that can't have the code.
+	tried := map[peer.ID]bool{}
+
+	for {
+		for _, pi := range discovery.PeersWithService(commons.MdnsServiceSend) {
+			if tried[pi.ID] {
+				continue
+			}
+
+			pairCtx, cancel := context.WithTimeout(ctx, pairingTimeout)
+			err := n.Pair(pairCtx, pi.ID, code)
+			cancel()
+
+			switch {
+			case err == nil:
+				state.peerID = pi.ID
+				close(state.done)
+				return pi.ID, nil
+			case errors.Is(err, ctx.Err()):
+				return "", err
+			case errors.Is(err, node.ErrUnknownPairingCode):
+				tried[pi.ID] = true
+			case errors.Is(err, node.ErrPairingRejected):
+				tried[pi.ID] = true
+				log.Infof("Pairing with %s failed: %s\n", pi.ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-events:
+		case <-time.After(pairingRetryInterval):
+		}
+	}
+}
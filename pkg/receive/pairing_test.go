@@ -0,0 +1,133 @@
+package receive
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// senderDiscoverer finds a single sender as if it announced itself
+// via mDNS.
+type senderDiscoverer struct {
+	pi peer.AddrInfo
+}
+
+func (s *senderDiscoverer) Start(ctx context.Context) error { return nil }
+func (s *senderDiscoverer) Stop() error                     { return nil }
+
+func (s *senderDiscoverer) Subscribe() (<-chan node.PeerEvent, func()) {
+	return make(chan node.PeerEvent), func() {}
+}
+
+func (s *senderDiscoverer) PeersList() []peer.AddrInfo {
+	return []peer.AddrInfo{s.pi}
+}
+
+func (s *senderDiscoverer) PeerInfo(peerID peer.ID) (*node.PeerInfo, bool) {
+	return node.NewPeerInfo(s.pi, node.SourceMDNS), peerID == s.pi.ID
+}
+
+func (s *senderDiscoverer) Service(peerID peer.ID) (string, bool) {
+	return commons.MdnsServiceSend, peerID == s.pi.ID
+}
+
+// profileContext returns a context with the configuration of the given
+// profile in a temporary configuration directory.
+func profileContext(t *testing.T, profile string) context.Context {
+	ctx, err := config.FillContext(context.Background(), profile)
+	require.NoError(t, err)
+	return ctx
+}
+
+// testNodes creates a receiver and the given number of other nodes
+// that are connected to it.
+func testNodes(t *testing.T, count int) (*Node, []*node.Node) {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+
+	receiver, err := InitNode(profileContext(t, "receiver"), []string{"/ip4/127.0.0.1/tcp/0"}, make(chan error, 1))
+	require.NoError(t, err)
+	t.Cleanup(func() { receiver.Close() })
+
+	var nodes []*node.Node
+	for i := 0; i < count; i++ {
+		n, err := node.Init(profileContext(t, "peer-"+string(rune('a'+i))), libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		require.NoError(t, err)
+		t.Cleanup(func() { n.Close() })
+		require.NoError(t, n.Connect(context.Background(), receiver.Peerstore().PeerInfo(receiver.ID())))
+		nodes = append(nodes, n)
+	}
+	return receiver, nodes
+}
+
+func TestNode_PairWithSender_onlyAcceptsPairedSender(t *testing.T) {
+	receiver, nodes := testNodes(t, 2)
+	sender, other := nodes[0], nodes[1]
+	ctx := context.Background()
+
+	receiver.Discovery = node.NewDiscovery(&senderDiscoverer{pi: sender.Peerstore().PeerInfo(sender.ID())})
+	receiver.expectPairing()
+	receiver.RegisterRequestHandler(receiver)
+
+	code, err := node.GeneratePairingCode()
+	require.NoError(t, err)
+	c, err := node.ContentID(strings.NewReader("file"))
+	require.NoError(t, err)
+	go sender.AwaitPairing(ctx, code)
+
+	// Push requests that arrive before the pairing is completed wait
+	// for it.
+	rejected := make(chan bool)
+	go func() {
+		accepted, err := other.SendPushRequest(ctx, receiver.ID(), p2p.NewPushRequest("file", 4, c))
+		rejected <- err == nil && !accepted
+	}()
+
+	paired, err := receiver.PairWithSender(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, sender.ID(), paired)
+
+	select {
+	case r := <-rejected:
+		assert.True(t, r, "the push request of the unpaired peer was rejected")
+	case <-time.After(pairingTimeout):
+		t.Fatal("the push request of the unpaired peer wasn't answered")
+	}
+
+	assert.True(t, receiver.isAllowed(sender.ID(), &node.Trust{}))
+	assert.False(t, receiver.isAllowed(other.ID(), &node.Trust{}))
+
+	// Not even linked devices are let through after pairing.
+	assert.False(t, receiver.isAllowed(other.ID(), &node.Trust{Linked: true}))
+}
+
+func TestPairingState_isPaired_timesOut(t *testing.T) {
+	defer func(timeout time.Duration) { pairingTimeout = timeout }(pairingTimeout)
+	pairingTimeout = 50 * time.Millisecond
+
+	n := &Node{}
+	state := n.expectPairing()
+	assert.Same(t, state, n.expectPairing(), "pairing is only expected once")
+
+	start := time.Now()
+	assert.False(t, n.isAllowed("peer-id", &node.Trust{Linked: true}))
+	assert.GreaterOrEqual(t, time.Since(start), pairingTimeout)
+
+	state.peerID = "peer-id"
+	close(state.done)
+	assert.True(t, n.isAllowed("peer-id", &node.Trust{}))
+	assert.False(t, n.isAllowed("other-peer-id", &node.Trust{}))
+}
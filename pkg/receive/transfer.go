@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
 
@@ -17,16 +18,23 @@ import (
 
 type TransferHandler struct {
 	peerID   peer.ID
+	dir      string
 	filename string
 	size     int64
 	cid      []byte
 	done     chan int64
+
+	// Whether an existing file is kept instead of replaced.
+	keep bool
 }
 
-func NewTransferHandler(peerID peer.ID, filename string, size int64, cid []byte, done chan int64) (*TransferHandler, error) {
+// NewTransferHandler creates a handler that saves the file into the
+// given directory, or into the current one if it's empty.
+func NewTransferHandler(peerID peer.ID, dir string, filename string, size int64, cid []byte, done chan int64) (*TransferHandler, error) {
 
 	th := &TransferHandler{
 		peerID:   peerID,
+		dir:      dir,
 		filename: filename,
 		size:     size,
 		cid:      cid,
@@ -36,24 +44,50 @@ func NewTransferHandler(peerID peer.ID, filename string, size int64, cid []byte,
 	return th, nil
 }
 
-func (th *TransferHandler) HandleTransfer(src io.Reader) {
+// HandleTransfer saves the received data and returns whether it was
+// checked against the content ID of the request.
+func (th *TransferHandler) HandleTransfer(src io.Reader) (bool, error) {
 	var received int64
 	defer func() {
 		th.done <- received
 		close(th.done)
 	}()
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return
+	dir := th.dir
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return false, err
+		}
+		dir = cwd
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Infoln(err)
+		return false, err
+	}
+
+	// Check the content ID, so the sender learns whether the file
+	// arrived intact.
+	verified := false
+	if c, err := cid.Cast(th.cid); err != nil {
+		log.Infoln("Can't verify the file:", err)
+	} else if vr, err := node.NewVerifiedReader(src, c); err != nil {
+		log.Infoln("Can't verify the file:", err)
+	} else {
+		src, verified = vr, true
 	}
 
 	filename := filepath.Base(th.filename)
+	path := filepath.Join(dir, filename)
 
-	log.Infoln("Saving file to: ", filepath.Join(cwd, filename))
-	f, err := os.Create(filepath.Join(cwd, filename))
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if th.keep {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	log.Infoln("Saving file to: ", path)
+	f, err := os.OpenFile(path, flag, 0666)
 	if err != nil {
-		return
+		return false, err
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -75,9 +109,16 @@ func (th *TransferHandler) HandleTransfer(src io.Reader) {
 	cancel()
 	wg.Wait()
 
-	if err != nil {
+	if errors.Is(err, node.ErrContentMismatch) {
+		log.Infoln("\nThe file doesn't match the content ID the sender announced and is removed.")
+		if err := os.Remove(path); err != nil {
+			log.Infoln(err)
+		}
+		received = -1
+	} else if err != nil {
 		log.Infoln(errors.Wrap(err, "error receiving or writing bytes"))
 	}
+	return verified, err
 }
 
 func (th *TransferHandler) GetLimit() int64 {
@@ -0,0 +1,71 @@
+package receive
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// describeCertificate returns the name and role of the given certificate.
+func describeCertificate(cert *p2p.Certificate) string {
+	if cert.Role == "" {
+		return cert.Name
+	}
+	return fmt.Sprintf("%s (%s)", cert.Name, cert.Role)
+}
+
+// logCertificateError explains why the certificate of the given peer
+// is ignored, unless it doesn't present one at all.
+func logCertificateError(peerID peer.ID, err error) {
+	if err != nil && !errors.Is(err, node.ErrNotCertified) {
+		log.Infof("Ignoring the certificate of peer %s: %s\n", peerID, err)
+	}
+}
+
+// shortAuthString returns the short authentication string of the
+// given push request or an empty string if the sender didn't attach
+// a session that it negotiated with us.
+func (n *Node) shortAuthString(peerID peer.ID, pr *p2p.PushRequest) string {
+	if len(pr.Session) == 0 {
+		return ""
+	} else if !n.TakeSession(peerID, pr.Session) {
+		log.Infof("Peer %s attached a session that wasn't negotiated\n", peerID)
+		return ""
+	}
+
+	sas, err := n.ShortAuthString(peerID, pr.Session)
+	if err != nil {
+		log.Infoln(err)
+		return ""
+	}
+	return sas
+}
+
+// verify marks the given peer as verified in the address book. Peers
+// that aren't contacts yet are added under their nickname, or their
+// peer ID if the nickname is taken.
+func (n *Node) verify(peerID peer.ID) error {
+	if n.contacts == nil {
+		return fmt.Errorf("no address book loaded")
+	}
+
+	contact, found := n.contacts.ByPeerID(peerID.String())
+	if !found {
+		contact = &config.Contact{Name: n.PresentedNickname(peerID), PeerID: peerID.String()}
+		if err := n.contacts.Add(contact); err != nil {
+			contact.Name = peerID.String()
+			if err = n.contacts.Add(contact); err != nil {
+				return err
+			}
+		}
+	}
+	contact.Verified = true
+
+	return n.contacts.Save()
+}
@@ -0,0 +1,65 @@
+package receive
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// Visibility controls who can discover the receiving node
+// and send push requests to it.
+type Visibility string
+
+const (
+	// VisibilityEveryone announces the node to everyone in the local network.
+	VisibilityEveryone Visibility = "everyone"
+
+	// VisibilityContacts announces the node but only answers info and push
+	// requests of peers in the address book or with a team certificate.
+	// The mDNS announcement, which holds the peer ID and the addresses,
+	// still reaches everyone in the local network.
+	VisibilityContacts Visibility = "contacts"
+
+	// VisibilityHidden doesn't announce the node at all. Peers need to
+	// know its address to connect.
+	VisibilityHidden Visibility = "hidden"
+)
+
+// ParseVisibility converts the given command line value into a Visibility.
+func ParseVisibility(s string) (Visibility, error) {
+	switch v := Visibility(s); v {
+	case VisibilityEveryone, VisibilityContacts, VisibilityHidden:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown visibility %q, expected one of everyone, contacts or hidden", s)
+	}
+}
+
+// SetVisibility configures which peers can discover this node and send
+// push requests to it. It must be called before the mDNS service is started.
+func (n *Node) SetVisibility(v Visibility) {
+	n.visibility = v
+	if v == VisibilityContacts {
+		n.SetPeerFilter(func(peerID peer.ID, info *p2p.InfoResponse) bool {
+			return n.PresentedTrust(peerID, info).IsTrusted()
+		})
+	} else {
+		n.SetPeerFilter(nil)
+	}
+}
+
+// isAllowed returns true if the given peer with the given trust may
+// send push requests with respect to the configured visibility and
+// pairing.
+func (n *Node) isAllowed(peerID peer.ID, trust *node.Trust) bool {
+	if pairing := n.pairing.Load(); pairing != nil {
+		return pairing.isPaired(peerID)
+	}
+	if n.visibility != VisibilityContacts {
+		return true
+	}
+	return trust.IsTrusted()
+}
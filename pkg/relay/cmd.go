@@ -0,0 +1,118 @@
+package relay
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/format"
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "relay",
+	Usage:  "Runs a circuit relay that forwards transfers between peers that can't reach each other directly.",
+	Action: Action,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:    "port",
+			EnvVars: []string{"P2P_PORT"},
+			Aliases: []string{"p"},
+			Usage:   "The port at which the relay is reachable.",
+			Value:   44047,
+		},
+		&cli.StringFlag{
+			Name:    "host",
+			EnvVars: []string{"P2P_HOST"},
+			Usage:   "The host at which the relay is reachable.",
+			Value:   "0.0.0.0",
+		},
+		&cli.Int64Flag{
+			Name:    "max-bytes",
+			EnvVars: []string{"P2P_RELAY_MAX_BYTES"},
+			Usage:   "The number of bytes relayed per connection and direction. Larger transfers are cut off. 0 means unlimited.",
+		},
+		&cli.DurationFlag{
+			Name:    "max-duration",
+			EnvVars: []string{"P2P_RELAY_MAX_DURATION"},
+			Usage:   "The time after which a relayed connection is closed. 0 means unlimited.",
+			Value:   30 * time.Minute,
+		},
+		commons.SwarmKeyFlag,
+	},
+	Description: `The relay subcommand runs a circuit relay v2 service. Receivers that pass one of
+the printed addresses to --relay reserve a slot at the relay and become reachable
+through it. Senders that pass the same address to --relay connect through the relay
+if they can't reach the receiver directly.`,
+}
+
+// Action is the function that is called when running p2p relay.
+func Action(c *cli.Context) error {
+
+	ctx, err := config.FillServiceContext(c.Context, c.String("profile"), "relay")
+	if err != nil {
+		return errors.Wrap(err, "failed loading configuration")
+	}
+
+	commons.ApplySwarmKeyFlag(ctx, c)
+
+	hostAddr := fmt.Sprintf("/ip4/%s/tcp/%d", c.String("host"), c.Int64("port"))
+	local, err := node.Init(ctx, libp2p.ListenAddrStrings(hostAddr))
+	if err != nil {
+		return errors.Wrap(err, "failed to init node")
+	}
+	defer local.Close()
+
+	limits := node.RelayLimits{
+		MaxBytes:    c.Int64("max-bytes"),
+		MaxDuration: c.Duration("max-duration"),
+	}
+	service, err := node.NewRelayService(local, limits)
+	if err != nil {
+		return errors.Wrap(err, "failed to start relay service")
+	}
+	defer service.Close()
+
+	addrs, err := local.DialableAddrs()
+	if err != nil {
+		return err
+	}
+	log.Infoln("Relay is reachable at:")
+	log.Infoln()
+	for _, addr := range addrs {
+		log.Infof("\t%s\n", addr)
+	}
+	log.Infoln()
+	log.Infof("Relayed connections are limited to %s and %s.\n", describeBytes(limits.MaxBytes), describeDuration(limits.MaxDuration))
+	log.Infoln("Waiting for peers... (cancel with ctrl+c)")
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	return nil
+}
+
+func describeBytes(n int64) string {
+	if n <= 0 {
+		return "unlimited data"
+	}
+	return format.Bytes(n) + " per direction"
+}
+
+func describeDuration(d time.Duration) string {
+	if d <= 0 {
+		return "unlimited time"
+	}
+	return d.String()
+}
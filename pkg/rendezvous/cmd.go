@@ -0,0 +1,82 @@
+package rendezvous
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "rendezvous",
+	Usage:  "Runs a rendezvous server that helps peers in different networks to find each other.",
+	Action: Action,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:    "port",
+			EnvVars: []string{"P2P_PORT"},
+			Aliases: []string{"p"},
+			Usage:   "The port at which the rendezvous server is reachable.",
+			Value:   44045,
+		},
+		&cli.StringFlag{
+			Name:    "host",
+			EnvVars: []string{"P2P_HOST"},
+			Usage:   "The host at which the rendezvous server is reachable.",
+			Value:   "0.0.0.0",
+		},
+		commons.SwarmKeyFlag,
+	},
+	Description: `The rendezvous subcommand starts a server at which peers register themselves
+and look for other peers. Pass one of the printed addresses to the --rendezvous
+flag of the send, receive and peers subcommands.`,
+}
+
+// Action is the function that is called when running p2p rendezvous.
+func Action(c *cli.Context) error {
+
+	ctx, err := config.FillServiceContext(c.Context, c.String("profile"), "rendezvous")
+	if err != nil {
+		return errors.Wrap(err, "failed loading configuration")
+	}
+
+	commons.ApplySwarmKeyFlag(ctx, c)
+
+	hostAddr := fmt.Sprintf("/ip4/%s/tcp/%d", c.String("host"), c.Int64("port"))
+	local, err := node.Init(ctx, libp2p.ListenAddrStrings(hostAddr))
+	if err != nil {
+		return errors.Wrap(err, "failed to init node")
+	}
+	defer local.Close()
+
+	server := node.NewRendezvousServer(local)
+	defer server.Close()
+
+	addrs, err := local.DialableAddrs()
+	if err != nil {
+		return err
+	}
+	log.Infoln("Rendezvous server is reachable at:")
+	log.Infoln()
+	for _, addr := range addrs {
+		log.Infof("\t%s\n", addr)
+	}
+	log.Infoln()
+	log.Infoln("Waiting for peers... (cancel with ctrl+c)")
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	return nil
+}
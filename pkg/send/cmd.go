@@ -2,26 +2,54 @@ package send
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
 	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
 	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
 )
 
+// The time to wait for more peer events before redrawing the peer list.
+var redrawDelay = 250 * time.Millisecond
+
 // Command .
 var Command = &cli.Command{
-	Name:        "send",
-	Usage:       "Sends a file to a peer in your local network.",
-	Aliases:     []string{"s"},
-	Action:      Action,
-	Flags:       []cli.Flag{},
+	Name:    "send",
+	Usage:   "Sends a file to a peer in your local network.",
+	Aliases: []string{"s"},
+	Action:  Action,
+	Flags: append(append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "peer",
+			EnvVars: []string{"P2P_PEER"},
+			Usage:   "Skip discovery and connect directly to the given multiaddress or address book contact.",
+		},
+		&cli.StringFlag{
+			Name:    "transport",
+			EnvVars: []string{"P2P_TRANSPORT"},
+			Usage:   "Prefer the given transport (tcp or quic) for peers that offer several. Overrides the Transport setting.",
+		},
+		&cli.BoolFlag{
+			Name:    "code",
+			EnvVars: []string{"P2P_CODE"},
+			Usage:   "Print a short code like 7-purple-sausage that the receiver enters instead of choosing the peer from a list.",
+		},
+		commons.SwarmKeyFlag,
+	}, commons.DiscoveryFlags...), commons.RelayFlags...),
 	ArgsUsage:   "FILE",
 	UsageText:   `FILE: The file you want to transmit to your peer (required).`,
 	Description: ``,
@@ -31,97 +59,158 @@ var Command = &cli.Command{
 // mainly responsible for the Text-based User Interface(TUI) state handling and input parsing.
 func Action(c *cli.Context) error {
 
-	ctx, err := config.FillContext(c.Context)
+	ctx, err := config.FillContext(c.Context, c.String("profile"))
 	if err != nil {
 		return err
 	}
 
+	commons.ApplySwarmKeyFlag(ctx, c)
+
 	// Try to open the file to check if we have access
 	filepath := c.Args().First()
 	if err = verifyFileAccess(filepath); err != nil {
 		return err
 	}
 
+	if transport := c.String("transport"); transport != "" {
+		if conf, ok := config.FromContext(ctx); ok {
+			conf.Settings.Transport = transport
+		}
+	}
+
 	local, err := InitNode(ctx)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to init node"))
 	}
 	defer local.Close()
 
+	if server := c.String("rendezvous"); server != "" {
+		if _, err = local.UseRendezvous(server, c.String("rendezvous-namespace")); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("broadcast") {
+		local.UseBroadcast(c.Int("broadcast-port"))
+	}
+
+	if relay := c.String("relay"); relay != "" {
+		if err = local.UseRelay(relay); err != nil {
+			return err
+		}
+	}
+
+	if target := c.String("peer"); target != "" {
+		return transferDirect(ctx, local, target, filepath)
+	}
+
+	if c.Bool("code") {
+		return transferWithCode(ctx, local, filepath)
+	}
+
+	// Subscribe before starting, so no peer is missed.
+	events, unsubscribe := local.Discovery.Subscribe()
+	defer unsubscribe()
+
 	log.Infoln("Searching peers that are waiting to receive files...")
-	err = local.StartMdnsService(ctx)
+	err = local.Discovery.Start(ctx)
 	if err != nil {
 		return err
 	}
 
-	time.Sleep(local.MdnsInterval)
+	input, inputErr := readLines(os.Stdin)
 
-	peers := local.PeersList()
-	log.Infof("\nFound the following peer(s):\n")
-	local.PrintPeers(peers)
+	peers := local.Discovery.PeersWithRole(node.RoleReceiver)
+	printPeers(local, peers)
 
-	for {
-		if len(peers) == 0 {
-			log.Info("No peer found in your local network [r,q,?]: ")
-		} else {
-			log.Info("Select the peer you want to send the file to [#,r,q,?]: ")
-		}
+	// Peers often come in bursts, so wait a moment before redrawing.
+	var redraw <-chan time.Time
 
-		scanner := bufio.NewScanner(os.Stdin)
-		if !scanner.Scan() {
-			return scanner.Err()
+	for {
+		var in string
+		select {
+		case <-events:
+			if redraw == nil {
+				redraw = time.After(redrawDelay)
+			}
+			continue
+		case <-redraw:
+			redraw = nil
+			// Indexes must not change while the user may be typing one.
+			var added bool
+			if peers, added = appendPeers(peers, local.Discovery.PeersWithRole(node.RoleReceiver)); added {
+				log.Infoln()
+				printPeers(local, peers)
+			}
+			continue
+		case line, ok := <-input:
+			if !ok {
+				return inputErr()
+			}
+			in = strings.TrimSpace(line)
 		}
 
-		// user input
-		input := strings.TrimSpace(scanner.Text())
-
 		// Empty input, user just pressed enter => do nothing and prompt again
-		if input == "" {
+		if in == "" {
+			prompt(peers)
 			continue
 		}
 
 		// Refresh the set of peers and prompt again
-		if input == "r" {
-			peers = local.PeersList()
-			if len(peers) > 0 {
-				log.Infof("\nFound the following peer(s):\n")
-				local.PrintPeers(peers)
-			}
+		if in == "r" {
+			peers = local.Discovery.PeersWithRole(node.RoleReceiver)
+			printPeers(local, peers)
 			continue
 		}
 
 		// Quit the process
-		if input == "q" {
+		if in == "q" {
 			return nil
 		}
 
 		// Print the help text and prompt again
-		if input == "?" {
+		if in == "?" {
 			help()
+			prompt(peers)
 			continue
 		}
 
 		if len(peers) == 0 {
 			log.Infoln("Invalid input")
+			prompt(peers)
 			continue
 		}
 
-		// Try to parse the input and
-		num, err := strconv.Atoi(input)
+		selection, err := parseSelection(in, len(peers))
 		if err != nil {
-			log.Infoln("Invalid input")
-			continue
-		} else if num >= len(peers) {
-			log.Infoln("Peer index out of range")
+			log.Infoln(err)
+			prompt(peers)
 			continue
 		}
 
+		// The user selected several peers
+		if len(selection) > 1 {
+			var selected []peer.AddrInfo
+			for _, num := range selection {
+				selected = append(selected, peers[num])
+			}
+
+			results := local.TransferAll(ctx, selected, filepath)
+			if !printSummary(results) {
+				prompt(peers)
+				continue
+			}
+			return nil
+		}
+
 		// The user entered a valid peer index
-		accepted, err := local.Transfer(ctx, peers[num], filepath)
+		accepted, err := local.Transfer(ctx, peers[selection[0]], filepath)
 		if err != nil {
 			log.Infoln(err)
+			prompt(peers)
 			continue
 		} else if !accepted {
+			prompt(peers)
 			continue
 		}
 
@@ -129,10 +218,170 @@ func Action(c *cli.Context) error {
 	}
 }
 
+// appendPeers appends the peers of found that aren't in peers yet
+// and reports whether there were any. Peers that left are kept, so
+// the indexes of the others stay the same.
+func appendPeers(peers []peer.AddrInfo, found []peer.AddrInfo) ([]peer.AddrInfo, bool) {
+	known := map[peer.ID]bool{}
+	for _, pi := range peers {
+		known[pi.ID] = true
+	}
+
+	added := false
+	for _, pi := range found {
+		if !known[pi.ID] {
+			peers = append(peers, pi)
+			added = true
+		}
+	}
+	return peers, added
+}
+
+// parseSelection parses the given input, which is a comma separated
+// list of peer indexes like 0,2,4 or all, for the given number of peers.
+func parseSelection(in string, count int) ([]int, error) {
+	if in == "all" {
+		selection := make([]int, count)
+		for i := range selection {
+			selection[i] = i
+		}
+		return selection, nil
+	}
+
+	var selection []int
+	seen := map[int]bool{}
+	for _, field := range strings.Split(in, ",") {
+		num, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid input")
+		} else if num < 0 || num >= count {
+			return nil, fmt.Errorf("peer index out of range")
+		}
+
+		if !seen[num] {
+			seen[num] = true
+			selection = append(selection, num)
+		}
+	}
+	return selection, nil
+}
+
+// printSummary prints the result of the transfer to each peer and
+// returns true if at least one peer accepted or already had the file.
+func printSummary(results []*Result) bool {
+	counts := map[string]int{}
+	accepted := 0
+
+	log.Infoln("Summary:")
+	log.Infoln()
+	tw := tabwriter.NewWriter(log.Out, 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		status := r.Status()
+		counts[status]++
+		if r.Accepted {
+			accepted++
+		}
+
+		if r.Err != nil {
+			fmt.Fprintf(tw, "\t%s\t%s: %s\n", r.Peer.ID, status, r.Err)
+		} else {
+			fmt.Fprintf(tw, "\t%s\t%s\n", r.Peer.ID, status)
+		}
+	}
+	tw.Flush()
+
+	log.Infof("\n%d accepted, %d rejected, %d failed, %d verified, %d unverified, %d skipped\n",
+		accepted, counts["rejected"], counts["failed"], counts["verified"], counts["unverified"], counts["skipped"])
+	return accepted+counts["skipped"] > 0
+}
+
+// printPeers prints the given list of peers followed by the prompt.
+func printPeers(local *Node, peers []peer.AddrInfo) {
+	if len(peers) > 0 {
+		log.Infof("\nFound the following peer(s):\n")
+		local.Discovery.PrintPeers(peers)
+	}
+	prompt(peers)
+}
+
+// prompt asks the user to select one of the given peers.
+func prompt(peers []peer.AddrInfo) {
+	if len(peers) == 0 {
+		log.Info("No peer found in your local network yet. Waiting for peers... [q,?]: ")
+	} else {
+		log.Info("Select the peer you want to send the file to [#,q,?]: ")
+	}
+}
+
+// readLines reads the given reader line by line in the background.
+// The returned channel is closed when the reader is drained, after
+// which the returned function reports the error that occurred, if any.
+func readLines(r io.Reader) (<-chan string, func() error) {
+	lines := make(chan string)
+	scanner := bufio.NewScanner(r)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines, scanner.Err
+}
+
+// transferDirect sends the file to the peer given by a multiaddress
+// or an address book entry without looking for peers via mDNS.
+func transferDirect(ctx context.Context, local *Node, target string, filepath string) error {
+	var ab *config.AddressBook
+	if conf, ok := config.FromContext(ctx); ok {
+		ab = conf.AddressBook
+	}
+
+	pi, err := node.ResolvePeer(ab, target)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Connecting to %s...\n", pi.ID)
+	_, err = local.Transfer(ctx, pi, filepath)
+	return err
+}
+
+// transferWithCode prints a pairing code and sends the file to the
+// peer that enters it.
+func transferWithCode(ctx context.Context, local *Node, filepath string) error {
+	code, err := node.GeneratePairingCode()
+	if err != nil {
+		return err
+	}
+
+	// Announce ourselves, so the receiver finds us.
+	if err = local.Discovery.Start(ctx); err != nil {
+		return err
+	}
+
+	log.Infof("Your pairing code is: %s\n\n", code)
+	log.Infof("On the other machine run:\n\n\tp2p receive %s\n\n", code)
+	log.Infoln("Waiting for the receiver... (cancel with ctrl+c)")
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	peerID, err := local.AwaitPairing(ctx, code)
+	if err != nil {
+		return err
+	}
+	log.Infof("Paired with %s\n", peerID)
+
+	_, err = local.Transfer(ctx, local.Peerstore().PeerInfo(peerID), filepath)
+	return err
+}
+
 // help prints the usage description for the user input in the "select peer" prompt.
 func help() {
-	log.Infoln("#: the number of the peer you want to connect to")
-	log.Infoln("r: refresh peer list")
+	log.Infoln("#: the number of the peer you want to send the file to")
+	log.Infoln("#,#,...: the numbers of several peers, e.g. 0,2,4, to send the file to all of them at once")
+	log.Infoln("all: send the file to all listed peers at once")
+	log.Infoln("r: refresh the peer list and drop the peers that left (new peers are added automatically)")
 	log.Infoln("q: quit p2p")
 	log.Infoln("?: this help message")
 }
@@ -0,0 +1,86 @@
+package send
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		in      string
+		count   int
+		want    []int
+		wantErr bool
+	}{
+		{in: "0", count: 1, want: []int{0}},
+		{in: "0,2,4", count: 5, want: []int{0, 2, 4}},
+		{in: "4,0", count: 5, want: []int{4, 0}},
+		{in: " 1 , 2 ", count: 3, want: []int{1, 2}},
+		{in: "1,1,2,1", count: 3, want: []int{1, 2}},
+		{in: "all", count: 3, want: []int{0, 1, 2}},
+		{in: "all", count: 0, want: []int{}},
+		{in: "3", count: 3, wantErr: true},
+		{in: "-1", count: 3, wantErr: true},
+		{in: "0,", count: 3, wantErr: true},
+		{in: "0;1", count: 3, wantErr: true},
+		{in: "ALL", count: 3, wantErr: true},
+		{in: "", count: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q of %d", tt.in, tt.count), func(t *testing.T) {
+			got, err := parseSelection(tt.in, tt.count)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResult_Status(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{result: Result{Accepted: true, Verified: true}, want: "verified"},
+		{result: Result{Accepted: true}, want: "unverified"},
+		{result: Result{}, want: "rejected"},
+		{result: Result{Skipped: true}, want: "skipped"},
+		{result: Result{Err: fmt.Errorf("offline")}, want: "failed"},
+		{result: Result{Accepted: true, Verified: true, Err: fmt.Errorf("reset")}, want: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.result.Status())
+		})
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	defer func(out io.Writer) { log.Out = out }(log.Out)
+	buffer := new(bytes.Buffer)
+	log.Out = buffer
+
+	results := []*Result{
+		{Peer: peer.AddrInfo{ID: "peer-1"}, Accepted: true, Verified: true},
+		{Peer: peer.AddrInfo{ID: "peer-2"}, Accepted: true},
+		{Peer: peer.AddrInfo{ID: "peer-3"}},
+		{Peer: peer.AddrInfo{ID: "peer-4"}, Skipped: true},
+		{Peer: peer.AddrInfo{ID: "peer-5"}, Accepted: true, Err: fmt.Errorf("reset")},
+	}
+	assert.True(t, printSummary(results))
+	assert.Contains(t, buffer.String(), "3 accepted, 1 rejected, 1 failed, 1 verified, 1 unverified, 1 skipped")
+
+	buffer.Reset()
+	assert.False(t, printSummary(results[2:3]))
+	assert.Contains(t, buffer.String(), "0 accepted, 1 rejected, 0 failed")
+}
@@ -8,14 +8,21 @@ import (
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
 	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
 	"github.com/ansuman12chat/p2p/pkg/progress"
 )
 
 type Node struct {
 	*node.Node
+
+	// The preferred transport, if any.
+	transport string
 }
 
 func InitNode(ctx context.Context) (*Node, error) {
@@ -25,7 +32,18 @@ func InitNode(ctx context.Context) (*Node, error) {
 		return nil, err
 	}
 
-	return &Node{n}, nil
+	n.SetRole(node.RoleSender)
+
+	// Announce ourselves as a sender, so other senders can
+	// ignore us, and only look for receivers.
+	n.SetMdnsServices(commons.MdnsServiceSend, commons.MdnsServiceReceive)
+
+	sn := &Node{Node: n}
+	if conf, ok := config.FromContext(ctx); ok {
+		sn.transport = conf.Settings.Transport
+	}
+
+	return sn, nil
 }
 
 func (n *Node) Close() error {
@@ -34,7 +52,7 @@ func (n *Node) Close() error {
 		log.Infoln(err)
 	}
 
-	err = n.StopMdnsService()
+	err = n.Discovery.Stop()
 	if err != nil {
 		log.Infoln(err)
 	}
@@ -43,32 +61,37 @@ func (n *Node) Close() error {
 }
 
 func (n *Node) Transfer(ctx context.Context, pi peer.AddrInfo, filepath string) (bool, error) {
-	// Connect to peer
-	err := n.Connect(ctx, pi)
-	if err != nil {
+	if err := n.connect(ctx, pi); err != nil {
 		return false, err
 	}
-	// Get content ID
-	c, err := calcContentID(filepath)
-	if err != nil {
-		return false, err
+
+	if n.IsRelayed(pi.ID) {
+		log.Infoln("The peer can't be reached directly. The transfer is relayed.")
+	} else if n.transport != "" {
+		log.Infof("Connected via %s.\n", n.ConnTransport(pi.ID))
 	}
 
-	f, err := os.Open(filepath)
+	f, req, err := newPushRequest(filepath)
 	if err != nil {
 		return false, err
 	}
 	defer f.Close()
 
-	// Get file info
-	fstat, err := f.Stat()
-	if err != nil {
+	// Show the short authentication string, so the receiver can
+	// verify us if it doesn't know our key yet.
+	var sas string
+	if req.Session, sas, err = n.negotiateSession(ctx, pi.ID); err != nil {
 		return false, err
+	} else if sas != "" {
+		log.Infof("Verification code: %s\n", sas)
 	}
 	log.Infof("Asking for confirmation... ")
 
-	accepted, err := n.SendPushRequest(ctx, pi.ID, path.Base(f.Name()), fstat.Size(), c)
-	if err != nil {
+	accepted, err := n.SendPushRequest(ctx, pi.ID, req)
+	if errors.Is(err, node.ErrHaveContent) {
+		log.Infoln("The peer already has the file. Skipped the transfer.")
+		return true, nil
+	} else if err != nil {
 		return false, err
 	}
 
@@ -84,13 +107,194 @@ func (n *Node) Transfer(ctx context.Context, pi peer.AddrInfo, filepath string)
 	wg.Add(1)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	go node.IndicateProgress(ctx, pr, path.Base(f.Name()), fstat.Size(), &wg)
+	go node.IndicateProgress(ctx, pr, req.Filename, req.Size, &wg)
 	defer func() { cancel(); wg.Wait() }()
 
-	if _, err = n.Node.Transfer(ctx, pi.ID, pr); err != nil {
+	_, verified, err := n.Node.Transfer(ctx, pi.ID, pr)
+	if err != nil {
 		return accepted, errors.Wrap(err, "could not transfer file to peer")
 	}
 
 	log.Infoln("Successfully sent file!")
+	if !verified {
+		log.Infoln("The peer couldn't verify that the file arrived intact.")
+	}
 	return accepted, nil
 }
+
+// Result is the outcome of sending a file to one of several peers.
+type Result struct {
+	Peer     peer.AddrInfo
+	Accepted bool
+
+	// Set if the peer already had the file, so it wasn't sent.
+	Skipped bool
+
+	// Set if the peer checked the content ID of the file.
+	Verified bool
+
+	// Why the transfer failed, if it did.
+	Err error
+}
+
+// Status describes the result as skipped, rejected, failed, verified or
+// unverified. The last two mean that the file was sent and whether the
+// peer checked its content ID, which older peers don't.
+func (r *Result) Status() string {
+	switch {
+	case r.Err != nil:
+		return "failed"
+	case r.Skipped:
+		return "skipped"
+	case !r.Accepted:
+		return "rejected"
+	case r.Verified:
+		return "verified"
+	default:
+		return "unverified"
+	}
+}
+
+// TransferAll sends the file to all given peers at once. The push
+// requests are sent concurrently, and the file is read only once for
+// the peers that accept it.
+func (n *Node) TransferAll(ctx context.Context, pis []peer.AddrInfo, filepath string) []*Result {
+	results := make([]*Result, len(pis))
+	for i, pi := range pis {
+		results[i] = &Result{Peer: pi}
+	}
+
+	f, req, err := newPushRequest(filepath)
+	if err != nil {
+		for _, r := range results {
+			r.Err = err
+		}
+		return results
+	}
+	defer f.Close()
+
+	log.Infof("Asking %d peers for confirmation...\n", len(pis))
+
+	var wg sync.WaitGroup
+	for _, r := range results {
+		wg.Add(1)
+		go func(r *Result) {
+			defer wg.Done()
+			r.Accepted, r.Err = n.request(ctx, r.Peer, req)
+			if errors.Is(r.Err, node.ErrHaveContent) {
+				r.Skipped, r.Err = true, nil
+				log.Infof("%s already has the file\n", r.Peer.ID)
+			} else if r.Err != nil {
+				log.Infof("Could not ask %s: %s\n", r.Peer.ID, r.Err)
+			} else if r.Accepted {
+				log.Infof("%s accepted\n", r.Peer.ID)
+			} else {
+				log.Infof("%s rejected\n", r.Peer.ID)
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	var accepted []*Result
+	var peerIDs []peer.ID
+	for _, r := range results {
+		if r.Accepted {
+			accepted = append(accepted, r)
+			peerIDs = append(peerIDs, r.Peer.ID)
+		}
+	}
+	if len(accepted) == 0 {
+		return results
+	}
+
+	// The progress of the source is the progress of the slowest peer.
+	pr := progress.NewReader(f)
+
+	var pwg sync.WaitGroup
+	pwg.Add(1)
+
+	pctx, cancel := context.WithCancel(context.Background())
+	go node.IndicateProgress(pctx, pr, req.Filename, req.Size, &pwg)
+
+	verified, errs := n.Node.TransferAll(ctx, peerIDs, pr)
+	cancel()
+	pwg.Wait()
+	log.Infoln()
+
+	for i, r := range accepted {
+		r.Verified = verified[i]
+		if errs[i] != nil {
+			r.Err = errors.Wrap(errs[i], "could not transfer file to peer")
+		}
+	}
+	return results
+}
+
+// request connects to the given peer and sends it a copy of the given
+// push request with its own session. It prints the short authentication
+// string for the peer and returns whether it accepted the request.
+func (n *Node) request(ctx context.Context, pi peer.AddrInfo, req *p2p.PushRequest) (bool, error) {
+	if err := n.connect(ctx, pi); err != nil {
+		return false, err
+	}
+
+	req = proto.Clone(req).(*p2p.PushRequest)
+
+	var err error
+	var sas string
+	if req.Session, sas, err = n.negotiateSession(ctx, pi.ID); err != nil {
+		return false, err
+	} else if sas != "" {
+		log.Infof("Verification code for %s: %s\n", pi.ID, sas)
+	}
+
+	return n.SendPushRequest(ctx, pi.ID, req)
+}
+
+// negotiateSession negotiates the session of a push request with the
+// given peer and returns it together with its short authentication
+// string. Both are empty if the peer can't negotiate one, e.g. because
+// it runs an older version.
+func (n *Node) negotiateSession(ctx context.Context, peerID peer.ID) ([]byte, string, error) {
+	session, err := n.NegotiateSession(ctx, peerID)
+	if err != nil {
+		log.Infof("No verification code for %s: %s\n", peerID, err)
+		return nil, "", nil
+	}
+
+	sas, err := n.ShortAuthString(peerID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, sas, nil
+}
+
+// connect connects to the given peer, through the relay if one is
+// configured.
+func (n *Node) connect(ctx context.Context, pi peer.AddrInfo) error {
+	return n.Connect(ctx, n.ViaRelay(pi))
+}
+
+// newPushRequest opens the given file and describes it in a push
+// request without a session. The caller needs to close the file.
+func newPushRequest(filepath string) (*os.File, *p2p.PushRequest, error) {
+	// Get content ID
+	c, err := calcContentID(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Get file info
+	fstat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return f, p2p.NewPushRequest(path.Base(f.Name()), fstat.Size(), c), nil
+}
@@ -0,0 +1,51 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// errAccessDenied is returned to peers that aren't allowed to pull.
+var errAccessDenied = errors.New("access denied")
+
+// Access controls which peers may pull shared files.
+type Access string
+
+const (
+	// AccessEveryone lets every peer that can connect pull the files.
+	AccessEveryone Access = "everyone"
+
+	// AccessContacts lets peers in the address book, linked devices
+	// and peers with a team certificate pull the files.
+	AccessContacts Access = "contacts"
+
+	// AccessVerified is like AccessContacts, but only lets contacts
+	// that are marked as verified pull the files.
+	AccessVerified Access = "verified"
+)
+
+// ParseAccess converts the given command line value into an Access.
+func ParseAccess(s string) (Access, error) {
+	switch a := Access(s); a {
+	case AccessEveryone, AccessContacts, AccessVerified:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown access %q, expected one of everyone, contacts or verified", s)
+	}
+}
+
+// isAuthorized returns true if the given peer may pull shared files
+// with respect to the configured access.
+func (n *Node) isAuthorized(peerID peer.ID) bool {
+	if n.access == AccessEveryone {
+		return true
+	}
+
+	trust := n.PeerTrust(peerID)
+	if n.access == AccessVerified {
+		return trust.Linked || trust.IsVerified()
+	}
+	return trust.IsTrusted()
+}
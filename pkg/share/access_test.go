@@ -0,0 +1,62 @@
+package share
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/libp2p/go-libp2p"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+func TestParseAccess(t *testing.T) {
+	for _, s := range []string{"everyone", "contacts", "verified"} {
+		a, err := ParseAccess(s)
+		assert.NoError(t, err)
+		assert.Equal(t, Access(s), a)
+	}
+	_, err := ParseAccess("nobody")
+	assert.Error(t, err)
+}
+
+func TestNode_isAuthorized(t *testing.T) {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+
+	ctx, err := config.FillContext(context.Background(), "server")
+	require.NoError(t, err)
+	conf, _ := config.FromContext(ctx)
+	server, err := InitNode(ctx, []string{"/ip4/127.0.0.1/tcp/0"}, AccessContacts)
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+
+	clientCtx, err := config.FillContext(context.Background(), "client")
+	require.NoError(t, err)
+	client, err := node.Init(clientCtx, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Connect(context.Background(), server.Peerstore().PeerInfo(server.ID())))
+
+	authorized := func() map[Access]bool {
+		result := map[Access]bool{}
+		for _, a := range []Access{AccessEveryone, AccessContacts, AccessVerified} {
+			server.access = a
+			result[a] = server.isAuthorized(client.ID())
+		}
+		return result
+	}
+
+	assert.Equal(t, map[Access]bool{AccessEveryone: true, AccessContacts: false, AccessVerified: false}, authorized(), "stranger")
+
+	contact := &config.Contact{Name: "client", PeerID: client.ID().String()}
+	require.NoError(t, conf.AddressBook.Add(contact))
+	assert.Equal(t, map[Access]bool{AccessEveryone: true, AccessContacts: true, AccessVerified: false}, authorized(), "contact")
+
+	contact.Verified = true
+	assert.Equal(t, map[Access]bool{AccessEveryone: true, AccessContacts: true, AccessVerified: true}, authorized(), "verified contact")
+}
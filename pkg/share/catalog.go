@@ -0,0 +1,105 @@
+package share
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+// catalog indexes the shared files by directory and by content ID.
+// Directories are identified by their slash separated path relative
+// to the root of the share, which is the empty path.
+type catalog struct {
+	dirs  map[string][]*p2p.ListEntry // entries by directory
+	files map[string]string           // local paths by content ID
+}
+
+func newCatalog() *catalog {
+	return &catalog{
+		dirs:  map[string][]*p2p.ListEntry{"": {}},
+		files: map[string]string{},
+	}
+}
+
+// add adds the given local file or directory at the given path of the
+// share. Hidden files, symbolic links and other special files are
+// skipped.
+func (c *catalog) add(root string, sharePath string) error {
+	defer c.sort()
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		current := node.CleanSharePath(path.Join(sharePath, filepath.ToSlash(rel)))
+
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := &p2p.ListEntry{Name: path.Base(current), ModTime: info.ModTime().Unix()}
+		switch {
+		case d.IsDir():
+			entry.Dir = true
+			if _, found := c.dirs[current]; found && current != "" {
+				return fmt.Errorf("%s is shared twice", current)
+			}
+			c.dirs[current] = []*p2p.ListEntry{}
+		case info.Mode().IsRegular():
+			cid, err := node.FileContentID(p)
+			if err != nil {
+				return err
+			}
+			entry.Size = info.Size()
+			entry.Cid = cid.Bytes()
+			c.files[cid.String()] = p
+		default:
+			return nil
+		}
+
+		// The root of the share isn't an entry itself.
+		if current == "" {
+			return nil
+		}
+
+		// Only the names of shared files and directories at the root
+		// may collide.
+		parent := node.CleanSharePath(path.Dir(current))
+		if parent == "" {
+			for _, sibling := range c.dirs[parent] {
+				if sibling.Name == entry.Name {
+					return fmt.Errorf("%s is shared twice", current)
+				}
+			}
+		}
+		c.dirs[parent] = append(c.dirs[parent], entry)
+		return nil
+	})
+}
+
+// sort sorts the entries of all directories by name.
+func (c *catalog) sort() {
+	for _, entries := range c.dirs {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package share
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+func writeFile(t *testing.T, path string, data string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+}
+
+func entryNames(entries []*p2p.ListEntry) []string {
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestNode_Share_singleDirectoryIsRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.txt"), "b")
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "images", "cat.png"), "cat")
+	writeFile(t, filepath.Join(dir, ".secret"), "hidden")
+	writeFile(t, filepath.Join(dir, ".git", "config"), "hidden")
+	writeFile(t, filepath.Join(dir, "images", ".thumbs"), "hidden")
+
+	n := &Node{catalog: newCatalog()}
+	require.NoError(t, n.Share(dir))
+
+	entries, err := n.Entries("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt", "images"}, entryNames(entries))
+	assert.True(t, entries[2].Dir)
+	assert.EqualValues(t, 1, entries[0].Size)
+
+	entries, err = n.Entries("images")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cat.png"}, entryNames(entries))
+
+	_, err = n.Entries(".git")
+	assert.Error(t, err)
+	assert.Len(t, n.catalog.files, 3)
+}
+
+func TestNode_Share_severalPathsUnderTheirNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "readme.md"), "readme")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "notes")
+
+	n := &Node{catalog: newCatalog()}
+	require.NoError(t, n.Share(filepath.Join(dir, "docs"), filepath.Join(dir, "notes.txt")))
+
+	entries, err := n.Entries("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "notes.txt"}, entryNames(entries))
+
+	entries, err = n.Entries("docs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"readme.md"}, entryNames(entries))
+}
+
+func TestNode_Share_rejectsCollisionsAtRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a", "notes.txt"), "a")
+	writeFile(t, filepath.Join(dir, "b", "notes.txt"), "b")
+	writeFile(t, filepath.Join(dir, "c", "docs", "x.txt"), "x")
+	writeFile(t, filepath.Join(dir, "d", "docs", "y.txt"), "y")
+
+	n := &Node{catalog: newCatalog()}
+	assert.Error(t, n.Share(filepath.Join(dir, "a", "notes.txt"), filepath.Join(dir, "b", "notes.txt")))
+
+	n = &Node{catalog: newCatalog()}
+	assert.Error(t, n.Share(filepath.Join(dir, "c", "docs"), filepath.Join(dir, "d", "docs")))
+}
+
+func TestNode_Share_indexesContentIDs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "same.txt")
+	writeFile(t, path, "content")
+	writeFile(t, filepath.Join(dir, "b.txt"), "other")
+
+	n := &Node{catalog: newCatalog()}
+	require.NoError(t, n.Share(dir))
+
+	c, err := node.FileContentID(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, n.catalog.files[c.String()])
+
+	entries, err := n.Entries("a")
+	require.NoError(t, err)
+	assert.Equal(t, c.Bytes(), entries[0].Cid)
+
+	n.access = AccessEveryone
+	shared, err := n.HandlePullRequest("peer-id", c)
+	require.NoError(t, err)
+	assert.Equal(t, path, shared)
+
+	other, err := node.ContentID(strings.NewReader("not shared"))
+	require.NoError(t, err)
+	_, err = n.HandlePullRequest("peer-id", other)
+	assert.ErrorIs(t, err, node.ErrNotShared)
+}
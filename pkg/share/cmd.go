@@ -0,0 +1,132 @@
+package share
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/commons"
+	"github.com/ansuman12chat/p2p/pkg/config"
+	"github.com/ansuman12chat/p2p/pkg/receive"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:   "share",
+	Usage:  "Lets peers browse and download files and directories from you while you are away.",
+	Action: Action,
+	Flags: append([]cli.Flag{
+		&cli.Int64Flag{
+			Name:    "port",
+			EnvVars: []string{"P2P_SHARE_PORT"},
+			Aliases: []string{"p"},
+			Usage:   "The TCP and UDP (QUIC) port at which you are reachable for other peers in the network. 0 picks a free port.",
+			Value:   44048,
+		},
+		&cli.StringSliceFlag{
+			Name:    "listen",
+			EnvVars: []string{"P2P_SHARE_LISTEN"},
+			Usage:   "Listen on the given multiaddress instead of all interfaces at --port. Can be repeated.",
+		},
+		&cli.StringFlag{
+			Name:    "access",
+			EnvVars: []string{"P2P_SHARE_ACCESS"},
+			Usage:   "Who may pull the files: everyone, contacts (address book, linked devices and certified peers) or verified (only verified contacts).",
+			Value:   string(AccessContacts),
+		},
+		commons.SwarmKeyFlag,
+	}, commons.RelayFlags...),
+	ArgsUsage: "FILE|DIR...",
+	Description: `The share subcommand serves the given files and directories until you stop it. Peers
+browse them with p2p ls and download them with p2p get or by their content ID with
+p2p pull, without you confirming each download. Every download is logged with the
+peer and the number of bytes sent.
+
+The files and directories show up under their names, unless you share a single
+directory, whose content is shared directly. Hidden files are skipped. Changes to
+the files are picked up when you restart p2p share.`,
+}
+
+// Action is the function that is called when running p2p share.
+func Action(c *cli.Context) error {
+	if c.Args().Len() == 0 {
+		return fmt.Errorf("please specify the files or directories you want to share")
+	}
+
+	ctx, err := config.FillContext(c.Context, c.String("profile"))
+	if err != nil {
+		return errors.Wrap(err, "failed loading configuration")
+	}
+
+	commons.ApplySwarmKeyFlag(ctx, c)
+
+	access, err := ParseAccess(c.String("access"))
+	if err != nil {
+		return err
+	}
+
+	listenAddrs := c.StringSlice("listen")
+	if len(listenAddrs) == 0 {
+		listenAddrs = receive.ListenAddrs(ctx, "0.0.0.0", "::", c.Int64("port"))
+	}
+
+	local, err := InitNode(ctx, listenAddrs, access)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize node")
+	}
+	defer local.Close()
+
+	if relay := c.String("relay"); relay != "" {
+		if err = local.UseRelay(relay); err != nil {
+			return err
+		}
+		if err = local.ReserveRelay(ctx); err != nil {
+			return err
+		}
+		defer local.StopRelay()
+	}
+
+	log.Infoln("Calculating content IDs...")
+	if err = local.Share(c.Args().Slice()...); err != nil {
+		return err
+	}
+
+	entries, err := local.Entries("")
+	if err != nil {
+		return err
+	}
+	log.Infoln()
+	for _, entry := range entries {
+		if entry.Dir {
+			log.Infof("\t%s/\n", entry.Name)
+		} else if contentID, err := cid.Cast(entry.Cid); err == nil {
+			log.Infof("\t%s\t%s\n", contentID, entry.Name)
+		}
+	}
+	log.Infoln()
+
+	addrs, err := local.DialableAddrs()
+	if err != nil {
+		return err
+	}
+	log.Infoln("Peers can browse them with p2p ls ADDRESS and download them with p2p get ADDRESS:PATH")
+	log.Infoln("or p2p pull ADDRESS CID from one of your addresses:")
+	log.Infoln()
+	for _, addr := range addrs {
+		log.Infof("\t%s\n", addr)
+	}
+	log.Infoln()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Infoln("Sharing files... (cancel with ctrl+c)")
+	<-ctx.Done()
+	return nil
+}
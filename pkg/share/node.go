@@ -0,0 +1,121 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/node"
+	p2p "github.com/ansuman12chat/p2p/pkg/pb"
+)
+
+type Node struct {
+	*node.Node
+	access Access
+
+	lk      sync.RWMutex // protects catalog
+	catalog *catalog
+}
+
+func InitNode(ctx context.Context, listenAddrs []string, access Access) (*Node, error) {
+
+	nn, err := node.Init(ctx, libp2p.ListenAddrStrings(listenAddrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{Node: nn, access: access, catalog: newCatalog()}
+
+	n.RegisterPullHandler(n)
+	n.RegisterListHandler(n)
+	return n, nil
+}
+
+// Share lets authorized peers browse and pull the given files and
+// directories. They show up under their names at the root of the
+// share, unless a single directory is shared, which becomes the root.
+// Changes to the files after sharing them aren't picked up.
+func (n *Node) Share(paths ...string) error {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	for _, p := range paths {
+		p, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+
+		fstat, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		sharePath := filepath.Base(p)
+		if len(paths) == 1 && fstat.IsDir() {
+			sharePath = ""
+		}
+
+		if err = n.catalog.add(p, sharePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries returns the entries of the shared directory at the given path.
+func (n *Node) Entries(path string) ([]*p2p.ListEntry, error) {
+	n.lk.RLock()
+	defer n.lk.RUnlock()
+
+	entries, found := n.catalog.dirs[path]
+	if !found {
+		return nil, fmt.Errorf("%q isn't a shared directory", path)
+	}
+	return entries, nil
+}
+
+// HandleListRequest returns the entries of the shared directory at
+// the given path if the given peer is authorized to list it.
+func (n *Node) HandleListRequest(peerID peer.ID, path string) ([]*p2p.ListEntry, error) {
+	if !n.isAuthorized(peerID) {
+		log.Infof("Refused list request of unauthorized peer %s\n", peerID)
+		return nil, errAccessDenied
+	}
+
+	log.Infof("Peer %s listed /%s\n", peerID, path)
+	return n.Entries(path)
+}
+
+// HandlePullRequest returns the path of the shared file with the
+// given content ID if the given peer is authorized to pull it.
+func (n *Node) HandlePullRequest(peerID peer.ID, c cid.Cid) (string, error) {
+	if !n.isAuthorized(peerID) {
+		log.Infof("Refused pull request of unauthorized peer %s\n", peerID)
+		return "", errAccessDenied
+	}
+
+	n.lk.RLock()
+	path, found := n.catalog.files[c.String()]
+	n.lk.RUnlock()
+
+	if !found {
+		log.Infof("Peer %s requested %s, which isn't shared\n", peerID, c)
+		return "", node.ErrNotShared
+	}
+
+	log.Infof("Peer %s requested %s\n", peerID, filepath.Base(path))
+	return path, nil
+}
+
+func (n *Node) Close() error {
+	n.UnregisterListHandler()
+	n.UnregisterPullHandler()
+	return n.Host.Close()
+}
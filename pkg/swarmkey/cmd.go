@@ -0,0 +1,56 @@
+package swarmkey
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ansuman12chat/p2p/internal/log"
+	"github.com/ansuman12chat/p2p/pkg/node"
+)
+
+// Command .
+var Command = &cli.Command{
+	Name:  "swarm-key",
+	Usage: "Manages the pre-shared keys of private swarms.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "generate",
+			Usage:     "Generates a new swarm key and prints it or writes it to the given file.",
+			ArgsUsage: "[FILE]",
+			Action:    GenerateAction,
+		},
+	},
+	Description: `Nodes that share a swarm key form a private swarm. They only connect to each other
+and neither see nor are seen by other nodes. Distribute the key file to all members
+and pass it to --swarm-key or set its path in the SwarmKey setting.`,
+}
+
+// GenerateAction creates a new swarm key.
+func GenerateAction(c *cli.Context) error {
+	key, err := node.GenerateSwarmKey()
+	if err != nil {
+		return err
+	}
+
+	path := c.Args().First()
+	if path == "" {
+		fmt.Print(string(key))
+		return nil
+	}
+
+	// Don't overwrite the key of an existing swarm by accident.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.Write(key); err != nil {
+		return err
+	}
+
+	log.Infof("Saved swarm key to %s\n", path)
+	return nil
+}